@@ -1,20 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"social-network/internal/config"
+	"social-network/internal/groups"
 	"social-network/internal/routes"
 	"social-network/internal/sessions"
 	"social-network/internal/database"
+	"social-network/internal/utils"
 	"social-network/internal/websocket"
+	"syscall"
 	"time"
 )
 
 func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
-		if origin == "http://localhost:5173" {
+		if utils.IsAllowedOrigin(origin) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
@@ -32,25 +40,91 @@ func enableCORS(next http.Handler) http.Handler {
 }
 
 func main() {
+	configPath := flag.String("config", "config.json", "path to the JSON config file (optional)")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
 	// Ensure uploads directory exists
-	if err := os.MkdirAll("./uploads", os.ModePerm); err != nil {
+	if err := os.MkdirAll(cfg.UploadDir, os.ModePerm); err != nil {
 		log.Fatal("Failed to create uploads directory:", err)
 	}
 
-	database.ConnectAndMigrate("internal/database/social.db", "file://internal/database/migrations/sqlite")
+	database.ConnectAndMigrate(cfg.DBPath, cfg.MigrationsPath)
 
-	// Start periodic cleanup of expired sessions
+	// Cancelled on SIGINT/SIGTERM to start shutting down
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start periodic cleanup of expired sessions and stale group invitations,
+	// and the event reminder scheduler
+	cleanupDone := make(chan struct{})
 	go func() {
+		defer close(cleanupDone)
 		ticker := time.NewTicker(1 * time.Hour)
-		for range ticker.C {
-			sessions.CleanupExpiredSessions()
+		defer ticker.Stop()
+		reminderTicker := time.NewTicker(cfg.ReminderInterval.Duration)
+		defer reminderTicker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sessions.CleanupExpiredSessions()
+				groups.ExpireStaleInvitations()
+			case <-reminderTicker.C:
+				groups.SendEventReminders(cfg.ReminderOffsets())
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
 	mux := http.NewServeMux()
 	manager := websocket.NewManager()
-	routes.RegisterRoutes(mux,manager)
+	routes.RegisterRoutes(mux, manager, cfg)
+
+	server := &http.Server{
+		Addr:    cfg.ListenAddr,
+		Handler: enableCORS(mux),
+	}
 
-	log.Println("Server running on http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", enableCORS(mux)))
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server running on %s", cfg.ListenAddr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
+	defer cancel()
+
+	// Stop accepting connections and wait for in-flight requests. Upgraded
+	// WebSocket connections are not tracked by the server, so close them separately.
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP shutdown: %v", err)
+	}
+	if err := manager.Shutdown(shutdownCtx); err != nil {
+		log.Printf("WebSocket shutdown: %v", err)
+	}
+
+	select {
+	case <-cleanupDone:
+	case <-shutdownCtx.Done():
+	}
+
+	if err := database.Close(); err != nil {
+		log.Printf("Closing database: %v", err)
+	}
+	log.Println("Server stopped")
 }
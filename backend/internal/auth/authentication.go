@@ -4,10 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 	"social-network/internal/models"
@@ -15,14 +12,13 @@ import (
 	"social-network/internal/queries"
 	"social-network/internal/database"
 	"social-network/internal/sessions"
-	"github.com/gofrs/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 
 
 func  HandleRegister(w http.ResponseWriter, r *http.Request) {
-	err := r.ParseMultipartForm(10 << 20)
+	err := utils.ParseUploadForm(w, r)
 	if err != nil {
 		http.Error(w, "Could not parse multipart form", http.StatusBadRequest)
 		return
@@ -46,31 +42,15 @@ func  HandleRegister(w http.ResponseWriter, r *http.Request) {
 	nickname := strings.TrimSpace(r.FormValue("nickname"))
 	dateOfBirth := r.FormValue("dateOfBirth")
 
-	var savedPath string
 	var filename string
 	// Handle image upload
 	file, header, err := r.FormFile("profileImage")
 	if err == nil {
 		defer file.Close()
-		
-		// Create unique filename
-		ext := filepath.Ext(header.Filename)
-		filename = uuid.Must(uuid.NewV4()).String() + ext
-		savedPath = "./uploads/" + filename
-		
-		// Create file
-		savedImage, err := os.Create(savedPath)
-		if err != nil {
-			fmt.Println(err, "in registration - creating file")
-			http.Error(w, "Could not save file", http.StatusInternalServerError)
-			return
-		}
-		defer savedImage.Close()
-		
-		// Copy file content
-		_, err = io.Copy(savedImage, file)
+
+		filename, err = utils.SaveUpload(file, header)
 		if err != nil {
-			fmt.Println(err, "in registration - copying file")
+			fmt.Println(err, "in registration - saving file")
 			http.Error(w, "Could not save file", http.StatusInternalServerError)
 			return
 		}
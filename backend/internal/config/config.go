@@ -0,0 +1,238 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the server settings. Values come from the defaults below, then
+// the JSON config file (if present), then SN_* environment variables.
+type Config struct {
+	ListenAddr     string   `json:"listen_addr"`
+	AllowedOrigins []string `json:"allowed_origins"`
+	DBPath         string   `json:"db_path"`
+	MigrationsPath string   `json:"migrations_path"`
+	UploadDir      string   `json:"upload_dir"`
+	MaxUploadSize  int64    `json:"max_upload_size"`
+	SessionTTL     Duration `json:"session_ttl"`
+	CookieSecure   bool     `json:"cookie_secure"`
+	CookieSameSite string   `json:"cookie_same_site"`
+
+	// ShutdownTimeout bounds draining requests and WebSocket connections on exit
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+
+	// EventReminderOffsets are how long before an event its attendees are
+	// reminded; ReminderInterval is how often the scheduler looks for due reminders
+	EventReminderOffsets []Duration `json:"event_reminder_offsets"`
+	ReminderInterval     Duration   `json:"reminder_interval"`
+}
+
+// Duration reads durations such as "24h" or "30m" from JSON
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"24h\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// Default returns the settings the server used before it was configurable
+func Default() *Config {
+	return &Config{
+		ListenAddr:     ":8080",
+		AllowedOrigins: []string{"http://localhost:5173"},
+		DBPath:         "internal/database/social.db",
+		MigrationsPath: "file://internal/database/migrations/sqlite",
+		UploadDir:      "./uploads",
+		MaxUploadSize:  10 << 20,
+		SessionTTL:     Duration{24 * time.Hour},
+		CookieSecure:   true,
+		CookieSameSite: "none",
+
+		ShutdownTimeout: Duration{10 * time.Second},
+
+		EventReminderOffsets: []Duration{{24 * time.Hour}, {time.Hour}},
+		ReminderInterval:     Duration{time.Minute},
+	}
+}
+
+// Load reads the config file at path, applies environment overrides and
+// validates the result. A missing file is not an error.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, cfg); err != nil {
+				return nil, fmt.Errorf("parsing config file: %w", err)
+			}
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) applyEnv() error {
+	if v, ok := os.LookupEnv("SN_LISTEN_ADDR"); ok {
+		c.ListenAddr = v
+	}
+	if v, ok := os.LookupEnv("SN_ALLOWED_ORIGINS"); ok {
+		c.AllowedOrigins = strings.Split(v, ",")
+	}
+	if v, ok := os.LookupEnv("SN_DB_PATH"); ok {
+		c.DBPath = v
+	}
+	if v, ok := os.LookupEnv("SN_MIGRATIONS_PATH"); ok {
+		c.MigrationsPath = v
+	}
+	if v, ok := os.LookupEnv("SN_UPLOAD_DIR"); ok {
+		c.UploadDir = v
+	}
+	if v, ok := os.LookupEnv("SN_MAX_UPLOAD_SIZE"); ok {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("SN_MAX_UPLOAD_SIZE: %w", err)
+		}
+		c.MaxUploadSize = size
+	}
+	if v, ok := os.LookupEnv("SN_SESSION_TTL"); ok {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("SN_SESSION_TTL: %w", err)
+		}
+		c.SessionTTL = Duration{ttl}
+	}
+	if v, ok := os.LookupEnv("SN_COOKIE_SECURE"); ok {
+		secure, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("SN_COOKIE_SECURE: %w", err)
+		}
+		c.CookieSecure = secure
+	}
+	if v, ok := os.LookupEnv("SN_COOKIE_SAMESITE"); ok {
+		c.CookieSameSite = v
+	}
+	if v, ok := os.LookupEnv("SN_SHUTDOWN_TIMEOUT"); ok {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("SN_SHUTDOWN_TIMEOUT: %w", err)
+		}
+		c.ShutdownTimeout = Duration{timeout}
+	}
+	if v, ok := os.LookupEnv("SN_EVENT_REMINDER_OFFSETS"); ok {
+		c.EventReminderOffsets = nil
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			offset, err := time.ParseDuration(part)
+			if err != nil {
+				return fmt.Errorf("SN_EVENT_REMINDER_OFFSETS: %w", err)
+			}
+			c.EventReminderOffsets = append(c.EventReminderOffsets, Duration{offset})
+		}
+	}
+	if v, ok := os.LookupEnv("SN_REMINDER_INTERVAL"); ok {
+		interval, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("SN_REMINDER_INTERVAL: %w", err)
+		}
+		c.ReminderInterval = Duration{interval}
+	}
+	return nil
+}
+
+// Validate rejects settings the server cannot run with
+func (c *Config) Validate() error {
+	if c.ListenAddr == "" {
+		return fmt.Errorf("listen_addr is required")
+	}
+	if c.DBPath == "" || c.MigrationsPath == "" {
+		return fmt.Errorf("db_path and migrations_path are required")
+	}
+	if c.UploadDir == "" {
+		return fmt.Errorf("upload_dir is required")
+	}
+	if c.MaxUploadSize <= 0 {
+		return fmt.Errorf("max_upload_size must be positive")
+	}
+	if c.SessionTTL.Duration <= 0 {
+		return fmt.Errorf("session_ttl must be positive")
+	}
+	if c.ShutdownTimeout.Duration <= 0 {
+		return fmt.Errorf("shutdown_timeout must be positive")
+	}
+	if c.ReminderInterval.Duration <= 0 {
+		return fmt.Errorf("reminder_interval must be positive")
+	}
+	for _, offset := range c.EventReminderOffsets {
+		if offset.Duration <= 0 {
+			return fmt.Errorf("event_reminder_offsets must be positive")
+		}
+	}
+
+	sameSite, err := c.SameSiteMode()
+	if err != nil {
+		return err
+	}
+	// Browsers drop SameSite=None cookies that are not Secure
+	if sameSite == http.SameSiteNoneMode && !c.CookieSecure {
+		return fmt.Errorf("cookie_same_site \"none\" requires cookie_secure")
+	}
+	return nil
+}
+
+// SameSiteMode converts CookieSameSite to its net/http value
+func (c *Config) SameSiteMode() (http.SameSite, error) {
+	switch strings.ToLower(strings.TrimSpace(c.CookieSameSite)) {
+	case "", "default":
+		return http.SameSiteDefaultMode, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("cookie_same_site must be one of default, lax, strict, none")
+	}
+}
+
+// ReminderOffsets returns EventReminderOffsets as plain durations
+func (c *Config) ReminderOffsets() []time.Duration {
+	offsets := make([]time.Duration, len(c.EventReminderOffsets))
+	for i, offset := range c.EventReminderOffsets {
+		offsets[i] = offset.Duration
+	}
+	return offsets
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_group_messages_group;
+DROP TABLE IF EXISTS group_messages;
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN last_seen_at DATETIME;
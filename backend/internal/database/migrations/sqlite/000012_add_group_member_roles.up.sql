@@ -0,0 +1,7 @@
+ALTER TABLE group_members ADD COLUMN role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'admin', 'moderator', 'member'));
+
+-- Existing creators own their groups
+UPDATE group_members SET role = 'owner'
+WHERE EXISTS (
+    SELECT 1 FROM groups g WHERE g.id = group_members.group_id AND g.creator_id = group_members.user_id
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_posts_group;
+
+ALTER TABLE posts DROP COLUMN group_id;
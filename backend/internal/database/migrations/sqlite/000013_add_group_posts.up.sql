@@ -0,0 +1,4 @@
+-- Posts can belong to a group; those are only shown to its members
+ALTER TABLE posts ADD COLUMN group_id INTEGER REFERENCES groups(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_posts_group ON posts (group_id, id);
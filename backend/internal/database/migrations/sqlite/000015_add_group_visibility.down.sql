@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_group_members_group;
+
+ALTER TABLE groups DROP COLUMN visibility;
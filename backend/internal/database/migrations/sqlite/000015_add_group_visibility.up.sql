@@ -0,0 +1,5 @@
+-- public: anyone can find and join, private: listed but joining needs approval,
+-- secret: hidden from the directory and invite-only
+ALTER TABLE groups ADD COLUMN visibility TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('public', 'private', 'secret'));
+
+CREATE INDEX IF NOT EXISTS idx_group_members_group ON group_members (group_id);
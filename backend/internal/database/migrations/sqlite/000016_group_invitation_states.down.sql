@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_group_invitations_pending;
+DROP INDEX IF EXISTS idx_group_join_requests_pending;
+
+ALTER TABLE group_invitations DROP COLUMN responded_at;
+ALTER TABLE group_join_requests DROP COLUMN responded_at;
@@ -0,0 +1,52 @@
+-- Older code stored the raw action ("accept"/"decline") as the status
+UPDATE group_invitations SET status = 'accepted' WHERE status = 'accept';
+UPDATE group_invitations SET status = 'declined' WHERE status NOT IN ('pending', 'accepted');
+UPDATE group_join_requests SET status = 'accepted' WHERE status = 'accept';
+UPDATE group_join_requests SET status = 'declined' WHERE status NOT IN ('pending', 'accepted');
+
+-- Only the newest of several pending duplicates survives
+UPDATE group_invitations SET status = 'cancelled'
+WHERE status = 'pending' AND id NOT IN (
+    SELECT MAX(id) FROM group_invitations WHERE status = 'pending' GROUP BY group_id, invited_user_id
+);
+UPDATE group_join_requests SET status = 'cancelled'
+WHERE status = 'pending' AND id NOT IN (
+    SELECT MAX(id) FROM group_join_requests WHERE status = 'pending' GROUP BY group_id, user_id
+);
+
+-- Rebuild both tables so the allowed states are enforced
+CREATE TABLE group_invitations_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    invited_user_id INTEGER NOT NULL,
+    invited_by_user_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled', 'expired')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    responded_at DATETIME,
+    FOREIGN KEY (group_id) REFERENCES groups(id),
+    FOREIGN KEY (invited_user_id) REFERENCES users(id),
+    FOREIGN KEY (invited_by_user_id) REFERENCES users(id)
+);
+INSERT INTO group_invitations_new (id, group_id, invited_user_id, invited_by_user_id, status, created_at)
+SELECT id, group_id, invited_user_id, invited_by_user_id, status, created_at FROM group_invitations;
+DROP TABLE group_invitations;
+ALTER TABLE group_invitations_new RENAME TO group_invitations;
+
+CREATE TABLE group_join_requests_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    group_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled', 'expired')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    responded_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (group_id) REFERENCES groups(id)
+);
+INSERT INTO group_join_requests_new (id, user_id, group_id, status, created_at)
+SELECT id, user_id, group_id, status, created_at FROM group_join_requests;
+DROP TABLE group_join_requests;
+ALTER TABLE group_join_requests_new RENAME TO group_join_requests;
+
+-- At most one pending invitation or request per user and group
+CREATE UNIQUE INDEX idx_group_invitations_pending ON group_invitations (group_id, invited_user_id) WHERE status = 'pending';
+CREATE UNIQUE INDEX idx_group_join_requests_pending ON group_join_requests (group_id, user_id) WHERE status = 'pending';
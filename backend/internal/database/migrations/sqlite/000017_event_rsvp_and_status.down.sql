@@ -0,0 +1,4 @@
+DELETE FROM notifications WHERE type IN ('group_event_updated', 'group_event_cancelled');
+DELETE FROM event_responses WHERE response = 'maybe';
+
+ALTER TABLE group_events DROP COLUMN status;
@@ -0,0 +1,43 @@
+-- One answer per user and event, which can now also be 'maybe'
+CREATE TABLE event_responses_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INT NOT NULL,
+    user_id INT NOT NULL,
+    group_id INT NOT NULL,
+    response VARCHAR(10) NOT NULL CHECK (response IN ('going', 'maybe', 'not_going')),
+    responded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    UNIQUE (event_id, user_id)
+);
+INSERT OR IGNORE INTO event_responses_new (id, event_id, user_id, group_id, response, responded_at)
+SELECT id, event_id, user_id, group_id, response, responded_at FROM event_responses WHERE response IS NOT NULL;
+DROP TABLE event_responses;
+ALTER TABLE event_responses_new RENAME TO event_responses;
+
+-- Cancelled events are kept so attendees can still see what happened
+ALTER TABLE group_events ADD COLUMN status TEXT NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'cancelled'));
+
+-- New notification types for event changes
+CREATE TABLE notifications_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    actor_id INTEGER NOT NULL,
+    type TEXT NOT NULL CHECK (type IN (
+        'follow_request', 'new_follower', 'group_invitation', 'group_join_request',
+        'group_event', 'group_event_updated', 'group_event_cancelled'
+    )),
+    group_id INTEGER,
+    reference_id INTEGER,
+    message TEXT NOT NULL CHECK (length(message) <= 500),
+    is_read BOOLEAN DEFAULT FALSE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+INSERT INTO notifications_new SELECT * FROM notifications;
+DROP TABLE notifications;
+ALTER TABLE notifications_new RENAME TO notifications;
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, is_read);
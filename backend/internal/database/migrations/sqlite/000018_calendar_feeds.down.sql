@@ -0,0 +1,3 @@
+ALTER TABLE group_events DROP COLUMN sequence;
+
+DROP TABLE IF EXISTS calendar_tokens;
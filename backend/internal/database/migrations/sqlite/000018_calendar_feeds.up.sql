@@ -0,0 +1,10 @@
+-- Secret token per user for subscribing to their events from a calendar app
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    user_id INTEGER PRIMARY KEY,
+    token TEXT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Bumped on every change so calendar apps replace their copy of the event
+ALTER TABLE group_events ADD COLUMN sequence INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,23 @@
+DROP TABLE IF EXISTS event_occurrence_cancellations;
+
+-- Only the first occurrence's answers fit the old one-answer-per-event table
+CREATE TABLE event_responses_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INT NOT NULL,
+    user_id INT NOT NULL,
+    group_id INT NOT NULL,
+    response VARCHAR(10) NOT NULL CHECK (response IN ('going', 'maybe', 'not_going')),
+    responded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    UNIQUE (event_id, user_id)
+);
+INSERT INTO event_responses_old (id, event_id, user_id, group_id, response, responded_at)
+SELECT id, event_id, user_id, group_id, response, responded_at FROM event_responses WHERE occurrence = 0;
+DROP TABLE event_responses;
+ALTER TABLE event_responses_old RENAME TO event_responses;
+
+ALTER TABLE group_events DROP COLUMN recurrence_count;
+ALTER TABLE group_events DROP COLUMN recurrence_until;
+ALTER TABLE group_events DROP COLUMN recurrence;
@@ -0,0 +1,35 @@
+-- Events can repeat daily, weekly or monthly until a date or for a number of
+-- occurrences. Occurrences are numbered from 0 (the event_time itself).
+ALTER TABLE group_events ADD COLUMN recurrence TEXT CHECK (recurrence IN ('daily', 'weekly', 'monthly'));
+ALTER TABLE group_events ADD COLUMN recurrence_until DATETIME;
+ALTER TABLE group_events ADD COLUMN recurrence_count INTEGER;
+
+-- Answers are given per occurrence; single events only have occurrence 0
+CREATE TABLE event_responses_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INT NOT NULL,
+    user_id INT NOT NULL,
+    group_id INT NOT NULL,
+    occurrence INTEGER NOT NULL DEFAULT 0,
+    response VARCHAR(10) NOT NULL CHECK (response IN ('going', 'maybe', 'not_going')),
+    responded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    UNIQUE (event_id, user_id, occurrence)
+);
+INSERT INTO event_responses_new (id, event_id, user_id, group_id, response, responded_at)
+SELECT id, event_id, user_id, group_id, response, responded_at FROM event_responses;
+DROP TABLE event_responses;
+ALTER TABLE event_responses_new RENAME TO event_responses;
+
+-- Single occurrences of a recurring event that were called off
+CREATE TABLE IF NOT EXISTS event_occurrence_cancellations (
+    event_id INTEGER NOT NULL,
+    occurrence INTEGER NOT NULL,
+    cancelled_by INTEGER NOT NULL,
+    cancelled_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (event_id, occurrence),
+    FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE,
+    FOREIGN KEY (cancelled_by) REFERENCES users(id)
+);
@@ -0,0 +1,3 @@
+DELETE FROM notifications WHERE type = 'group_event_reminder';
+
+DROP TABLE IF EXISTS event_reminders;
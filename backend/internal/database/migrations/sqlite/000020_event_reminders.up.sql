@@ -0,0 +1,35 @@
+-- Reminders already sent, so restarts never send the same one twice. offset
+-- is how many seconds before the occurrence the reminder was due.
+CREATE TABLE IF NOT EXISTS event_reminders (
+    event_id INTEGER NOT NULL,
+    occurrence INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    offset_seconds INTEGER NOT NULL,
+    sent_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (event_id, occurrence, user_id, offset_seconds),
+    FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- New notification type for reminders
+CREATE TABLE notifications_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    actor_id INTEGER NOT NULL,
+    type TEXT NOT NULL CHECK (type IN (
+        'follow_request', 'new_follower', 'group_invitation', 'group_join_request',
+        'group_event', 'group_event_updated', 'group_event_cancelled', 'group_event_reminder'
+    )),
+    group_id INTEGER,
+    reference_id INTEGER,
+    message TEXT NOT NULL CHECK (length(message) <= 500),
+    is_read BOOLEAN DEFAULT FALSE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+INSERT INTO notifications_new SELECT * FROM notifications;
+DROP TABLE notifications;
+ALTER TABLE notifications_new RENAME TO notifications;
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, is_read);
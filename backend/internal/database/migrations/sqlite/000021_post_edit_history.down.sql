@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_post_edits_post;
+
+DROP TABLE IF EXISTS post_edits;
@@ -0,0 +1,13 @@
+-- Earlier versions of a post, one row per edit, holding what the post looked
+-- like before that edit
+CREATE TABLE IF NOT EXISTS post_edits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id INTEGER NOT NULL,
+    content TEXT NOT NULL CHECK (length(content) <= 1000),
+    image TEXT CHECK (length(image) <= 500),
+    privacy TEXT NOT NULL,
+    edited_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_edits_post ON post_edits (post_id, id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_post_comments_thread;
+
+-- Replies cannot be told apart from top-level comments any more
+DELETE FROM post_comments WHERE parent_id IS NOT NULL;
+ALTER TABLE post_comments DROP COLUMN updated_at;
+ALTER TABLE post_comments DROP COLUMN parent_id;
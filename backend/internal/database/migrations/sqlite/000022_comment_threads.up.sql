@@ -0,0 +1,6 @@
+-- Replies point at the comment they answer; top-level comments have no parent.
+-- updated_at is only set once a comment has been edited.
+ALTER TABLE post_comments ADD COLUMN parent_id INTEGER REFERENCES post_comments(id) ON DELETE CASCADE;
+ALTER TABLE post_comments ADD COLUMN updated_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_post_comments_thread ON post_comments (post_id, parent_id, id);
@@ -47,5 +47,12 @@ func ConnectAndMigrate(dbPath string, migrationsPath string) {
 	}
 
 	fmt.Println("Migrations applied successfully.")
-	
+}
+
+// Close closes the global DB handle
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	return DB.Close()
 }
@@ -0,0 +1,217 @@
+package groups
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/models"
+	"social-network/internal/queries"
+	"social-network/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IsBanned reports whether userID is banned from groupID
+func IsBanned(userID, groupID int) (bool, error) {
+	var banned bool
+	err := database.DB.QueryRow(queries.IsGroupBannedQuery, groupID, userID).Scan(&banned)
+	return banned, err
+}
+
+// rejectBanned replies 403 when userID is banned from groupID
+func rejectBanned(w http.ResponseWriter, userID, groupID int) bool {
+	banned, err := IsBanned(userID, groupID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return true
+	}
+	if banned {
+		http.Error(w, "This user is banned from the group", http.StatusForbidden)
+		return true
+	}
+	return false
+}
+
+// GetGroupMembers lists a group's members with their profile info and roles
+func GetGroupMembers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.URL.Query().Get("group_id"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+	if !requireMember(w, userID, groupID) {
+		return
+	}
+
+	rows, err := database.DB.Query(queries.GetGroupMemberListQuery, groupID)
+	if err != nil {
+		http.Error(w, "Failed to load members", http.StatusInternalServerError)
+		fmt.Println("Error executing GetGroupMemberListQuery in GetGroupMembers:", err)
+		return
+	}
+	defer rows.Close()
+
+	members := []models.GroupMember{}
+	for rows.Next() {
+		var m models.GroupMember
+		err := rows.Scan(&m.ID, &m.Nickname, &m.FirstName, &m.LastName, &m.ProfilePic, &m.Role, &m.JoinedAt)
+		if err != nil {
+			http.Error(w, "Failed to load members", http.StatusInternalServerError)
+			fmt.Println("Scan error in GetGroupMembers:", err)
+			return
+		}
+		m.ProfilePic = strings.Replace(m.ProfilePic, "./uploads/", "/uploads/", 1)
+		members = append(members, m)
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, members)
+}
+
+// BanMember removes a user from the group (if they are in it) and stops them
+// from being invited or asking to join again
+func BanMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req memberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == userID {
+		http.Error(w, "You cannot ban yourself", http.StatusBadRequest)
+		return
+	}
+
+	actorRole, ok := requirePermission(w, userID, req.GroupID, PermBanMembers)
+	if !ok {
+		return
+	}
+
+	targetRole, err := GetMemberRole(req.UserID, req.GroupID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if targetRole != "" && !outranks(actorRole, targetRole) {
+		http.Error(w, "You cannot ban that member", http.StatusForbidden)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	steps := []struct {
+		query string
+		args  []interface{}
+	}{
+		{queries.InsertGroupBanQuery, []interface{}{req.GroupID, req.UserID, userID}},
+		{queries.DeleteGroupMemberQuery, []interface{}{req.UserID, req.GroupID}},
+		{queries.DeclinePendingInvitationsQuery, []interface{}{req.GroupID, req.UserID}},
+		{queries.DeclinePendingJoinRequestsQuery, []interface{}{req.GroupID, req.UserID}},
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(step.query, step.args...); err != nil {
+			http.Error(w, "Failed to ban user", http.StatusInternalServerError)
+			fmt.Println("Error banning user in BanMember:", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Could not save changes", http.StatusInternalServerError)
+		return
+	}
+	leaveChatRoom(req.GroupID, req.UserID)
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "User banned"})
+}
+
+// UnbanMember lifts a ban; the user still has to be invited or request to join again
+func UnbanMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req memberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := requirePermission(w, userID, req.GroupID, PermBanMembers); !ok {
+		return
+	}
+
+	result, err := database.DB.Exec(queries.DeleteGroupBanQuery, req.GroupID, req.UserID)
+	if err != nil {
+		http.Error(w, "Failed to unban user", http.StatusInternalServerError)
+		fmt.Println("Error executing DeleteGroupBanQuery in UnbanMember:", err)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		http.Error(w, "User is not banned", http.StatusNotFound)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "User unbanned"})
+}
+
+// GetGroupBans lists the users banned from a group
+func GetGroupBans(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.URL.Query().Get("group_id"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+	if _, ok := requirePermission(w, userID, groupID, PermBanMembers); !ok {
+		return
+	}
+
+	rows, err := database.DB.Query(queries.GetGroupBansQuery, groupID)
+	if err != nil {
+		http.Error(w, "Failed to load bans", http.StatusInternalServerError)
+		fmt.Println("Error executing GetGroupBansQuery in GetGroupBans:", err)
+		return
+	}
+	defer rows.Close()
+
+	type bannedUser struct {
+		models.FollowUser
+		BannedAt time.Time `json:"bannedAt"`
+	}
+
+	bans := []bannedUser{}
+	for rows.Next() {
+		var b bannedUser
+		err := rows.Scan(&b.ID, &b.Nickname, &b.FirstName, &b.LastName, &b.ProfilePic, &b.BannedAt)
+		if err != nil {
+			http.Error(w, "Failed to load bans", http.StatusInternalServerError)
+			fmt.Println("Scan error in GetGroupBans:", err)
+			return
+		}
+		b.ProfilePic = strings.Replace(b.ProfilePic, "./uploads/", "/uploads/", 1)
+		bans = append(bans, b)
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, bans)
+}
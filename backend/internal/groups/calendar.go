@@ -0,0 +1,264 @@
+package groups
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/queries"
+	"social-network/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	calendarProdID = "-//social-network//Group Events//EN"
+	// Events only have a start time, so calendar entries get this length
+	calendarEventDuration = time.Hour
+	calendarTimeLayout    = "20060102T150405Z"
+	calendarFeedPath      = "/groups/calendar/feed"
+)
+
+// calendarEvent is a group event as it is exported to calendar apps
+type calendarEvent struct {
+	ID          int
+	Title       string
+	Description string
+	EventTime   string
+	Frequency   string
+	Status      string
+	Sequence    int
+	GroupTitle  string
+	Occurrence  int
+}
+
+func scanCalendarEvent(row interface{ Scan(...interface{}) error }, extra ...interface{}) (calendarEvent, error) {
+	var ev calendarEvent
+	dest := []interface{}{&ev.ID, &ev.Title, &ev.Description, &ev.EventTime, &ev.Frequency, &ev.Status, &ev.Sequence, &ev.GroupTitle}
+	err := row.Scan(append(dest, extra...)...)
+	return ev, err
+}
+
+// ExportGroupEvent downloads a single event as an .ics file. For recurring
+// events the occurrence query parameter picks the occurrence.
+func ExportGroupEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	eventID, err := strconv.Atoi(r.URL.Query().Get("event_id"))
+	if err != nil {
+		http.Error(w, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+	ev, err := loadEvent(eventID)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !requireMember(w, userID, ev.GroupID) {
+		return
+	}
+	age, err := GetUserAge(userID)
+	if err != nil {
+		http.Error(w, "Could not determine your age", http.StatusInternalServerError)
+		return
+	}
+	if !meetsAgeRestriction(age, ev.Age) {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	n, ok := occurrenceParam(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := eventOccurrence(ev, n); !ok {
+		http.Error(w, "Occurrence not found", http.StatusNotFound)
+		return
+	}
+
+	calEvent, err := scanCalendarEvent(database.DB.QueryRow(queries.GetCalendarEventQuery, n, eventID))
+	if err != nil {
+		http.Error(w, "Failed to load event", http.StatusInternalServerError)
+		fmt.Println("Error executing GetCalendarEventQuery in ExportGroupEvent:", err)
+		return
+	}
+	calEvent.Occurrence = n
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="event-%d.ics"`, eventID))
+	writeCalendar(w, calEvent.GroupTitle, []calendarEvent{calEvent})
+}
+
+// CalendarToken returns the user's calendar feed URL, creating the token on
+// first use. POST replaces the token, so old subscription links stop working.
+func CalendarToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var token string
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		err = database.DB.QueryRow(queries.GetCalendarTokenQuery, userID).Scan(&token)
+		if err == sql.ErrNoRows {
+			token, err = newCalendarToken(userID)
+		}
+	case http.MethodPost:
+		token, err = newCalendarToken(userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load calendar token", http.StatusInternalServerError)
+		fmt.Println("Error loading calendar token in CalendarToken:", err)
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{
+		"token": token,
+		"url":   scheme + "://" + r.Host + calendarFeedPath + "?token=" + token,
+	})
+}
+
+func newCalendarToken(userID int) (string, error) {
+	token := uuid.Must(uuid.NewV4()).String()
+	_, err := database.DB.Exec(queries.UpsertCalendarTokenQuery, userID, token)
+	return token, err
+}
+
+// CalendarFeed serves every event the token's owner is going to as an iCalendar
+// feed. Calendar apps cannot send the session cookie, so the token is the only
+// credential.
+func CalendarFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var userID int
+	err := database.DB.QueryRow(queries.GetCalendarTokenUserQuery, r.URL.Query().Get("token")).Scan(&userID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Calendar not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		fmt.Println("Error executing GetCalendarTokenUserQuery in CalendarFeed:", err)
+		return
+	}
+
+	age, err := GetUserAge(userID)
+	if err != nil {
+		http.Error(w, "Could not determine your age", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := database.DB.Query(queries.GetCalendarFeedEventsQuery, userID, age)
+	if err != nil {
+		http.Error(w, "Failed to load events", http.StatusInternalServerError)
+		fmt.Println("Error executing GetCalendarFeedEventsQuery in CalendarFeed:", err)
+		return
+	}
+	defer rows.Close()
+
+	var events []calendarEvent
+	for rows.Next() {
+		var n int
+		ev, err := scanCalendarEvent(rows, &n)
+		if err != nil {
+			http.Error(w, "Failed to load events", http.StatusInternalServerError)
+			fmt.Println("Scan error in CalendarFeed:", err)
+			return
+		}
+		ev.Occurrence = n
+		events = append(events, ev)
+	}
+
+	writeCalendar(w, "Group events", events)
+}
+
+// writeCalendar writes events as an iCalendar (RFC 5545) document
+func writeCalendar(w http.ResponseWriter, name string, events []calendarEvent) {
+	now := time.Now().UTC().Format(calendarTimeLayout)
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + calendarProdID,
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + escapeCalendarText(name),
+	}
+	for _, ev := range events {
+		start, ok := parseEventTime(ev.EventTime)
+		if !ok {
+			fmt.Println("Skipping event with unreadable time in calendar:", ev.ID, ev.EventTime)
+			continue
+		}
+		start = occurrenceStart(start, ev.Frequency, ev.Occurrence)
+		// The UID never changes, so calendar apps update the entry in place.
+		// Each occurrence of a recurring event is its own entry.
+		uid := fmt.Sprintf("group-event-%d@social-network", ev.ID)
+		if ev.Frequency != "" {
+			uid = fmt.Sprintf("group-event-%d-%d@social-network", ev.ID, ev.Occurrence)
+		}
+		status := "CONFIRMED"
+		if ev.Status == EventCancelled {
+			status = "CANCELLED"
+		}
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+uid,
+			"SEQUENCE:"+strconv.Itoa(ev.Sequence),
+			"DTSTAMP:"+now,
+			"DTSTART:"+start.Format(calendarTimeLayout),
+			"DTEND:"+start.Add(calendarEventDuration).Format(calendarTimeLayout),
+			"SUMMARY:"+escapeCalendarText(ev.Title),
+			"DESCRIPTION:"+escapeCalendarText(ev.Description),
+			"LOCATION:"+escapeCalendarText(ev.GroupTitle),
+			"STATUS:"+status,
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	for _, line := range lines {
+		fmt.Fprint(w, foldCalendarLine(line), "\r\n")
+	}
+}
+
+// escapeCalendarText escapes the characters that are special in iCalendar text values
+func escapeCalendarText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldCalendarLine splits lines longer than 75 bytes as required by RFC 5545,
+// never in the middle of a UTF-8 character
+func foldCalendarLine(line string) string {
+	const limit = 75
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
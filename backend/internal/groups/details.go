@@ -0,0 +1,221 @@
+package groups
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/models"
+	"social-network/internal/queries"
+	"social-network/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Number of upcoming events shown on the group page
+const upcomingEventsLimit = 5
+
+// GetGroup returns one group with its member count, the viewer's membership
+// and any pending request or invitation between them
+func GetGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.URL.Query().Get("group_id"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	var page models.GroupPage
+	err = database.DB.QueryRow(queries.GetGroupByIDQuery, groupID).Scan(
+		&page.ID, &page.Title, &page.Description, &page.Image, &page.CreatorID, &page.Visibility,
+	)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		fmt.Println("Error executing GetGroupByIDQuery in GetGroup:", err)
+		return
+	}
+
+	page.Role, err = GetMemberRole(userID, groupID)
+	if err == nil {
+		page.IsMember = page.Role != ""
+		err = database.DB.QueryRow(queries.GetGroupMemberCountQuery, groupID).Scan(&page.MemberCount)
+	}
+	if err == nil && !page.IsMember {
+		page.PendingRequestID, err = pendingID(database.DB, queries.GetPendingJoinRequestIDQuery, groupID, userID)
+		if err == nil {
+			page.PendingInvitationID, err = pendingID(database.DB, queries.GetPendingInvitationIDQuery, groupID, userID)
+		}
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		fmt.Println("Error loading group state in GetGroup:", err)
+		return
+	}
+
+	// Secret groups do not exist for anyone who has not been invited
+	if page.Visibility == VisibilitySecret && !page.IsMember && page.PendingInvitationID == 0 {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	page.UpcomingEvents = []models.Event{}
+	if page.IsMember {
+		page.UpcomingEvents, err = upcomingEvents(groupID, userID)
+		if err != nil {
+			http.Error(w, "Failed to load events", http.StatusInternalServerError)
+			fmt.Println("Error loading upcoming events in GetGroup:", err)
+			return
+		}
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, page)
+}
+
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// pendingID runs a query returning the ID of a pending request or invitation, or 0 if there is none
+func pendingID(db rowQuerier, query string, groupID, userID int) (int, error) {
+	var id int
+	err := db.QueryRow(query, groupID, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// upcomingEvents returns the next events in groupID that userID is old enough for
+func upcomingEvents(groupID, userID int) ([]models.Event, error) {
+	age, err := GetUserAge(userID)
+	if err != nil {
+		return nil, err
+	}
+	cancelled, err := loadOccurrenceSet(queries.GetGroupCancelledOccurrencesQuery, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(queries.GetUpcomingGroupEventsQuery, groupID, age)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	events := []models.Event{}
+	for rows.Next() {
+		ev, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, expandEvent(ev, now, time.Time{}, cancelled)...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sortEventsByTime(events)
+	if len(events) > upcomingEventsLimit {
+		events = events[:upcomingEventsLimit]
+	}
+	return events, nil
+}
+
+// UpdateGroup changes a group's title, description, visibility or image. Fields left out
+// of the form keep their current value; remove_image=true clears the image.
+func UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := utils.ParseUploadForm(w, r)
+	if err != nil {
+		http.Error(w, "Could not parse multipart form", http.StatusBadRequest)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.FormValue("group_id"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+	if _, ok := requirePermission(w, userID, groupID, PermEditGroup); !ok {
+		return
+	}
+
+	var group models.GroupDetails
+	err = database.DB.QueryRow(queries.GetGroupByIDQuery, groupID).Scan(
+		&group.ID, &group.Title, &group.Description, &group.Image, &group.CreatorID, &group.Visibility,
+	)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	if _, ok := r.MultipartForm.Value["title"]; ok {
+		group.Title = strings.TrimSpace(r.FormValue("title"))
+		if group.Title == "" {
+			http.Error(w, "Title is required", http.StatusBadRequest)
+			return
+		}
+	}
+	if _, ok := r.MultipartForm.Value["description"]; ok {
+		group.Description = r.FormValue("description")
+	}
+	if _, ok := r.MultipartForm.Value["visibility"]; ok {
+		group.Visibility = r.FormValue("visibility")
+		if !validVisibility(group.Visibility) {
+			http.Error(w, "Visibility must be public, private or secret", http.StatusBadRequest)
+			return
+		}
+	}
+
+	oldImage := group.Image
+	if r.FormValue("remove_image") == "true" {
+		group.Image = ""
+	}
+
+	file, header, err := r.FormFile("image")
+	if err == nil {
+		defer file.Close()
+
+		filename, err := utils.SaveUpload(file, header)
+		if err != nil {
+			fmt.Println(err, "in UpdateGroup - saving file")
+			http.Error(w, "Could not save file", http.StatusInternalServerError)
+			return
+		}
+		group.Image = "/uploads/" + filename
+	}
+
+	_, err = database.DB.Exec(queries.UpdateGroupQuery, group.Title, group.Description, group.Image, group.Visibility, groupID)
+	if err != nil {
+		http.Error(w, "Failed to update group", http.StatusInternalServerError)
+		fmt.Println("Error executing UpdateGroupQuery in UpdateGroup:", err)
+		if group.Image != oldImage {
+			utils.RemoveUpload(group.Image)
+		}
+		return
+	}
+
+	// The old file is only removed once nothing points at it any more
+	if oldImage != "" && oldImage != group.Image {
+		if err := utils.RemoveUpload(oldImage); err != nil {
+			fmt.Println("Error removing old group image in UpdateGroup:", err)
+		}
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, group)
+}
@@ -0,0 +1,157 @@
+package groups
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/models"
+	"social-network/internal/queries"
+	"social-network/internal/utils"
+	"strconv"
+	"strings"
+)
+
+// Group visibility settings
+const (
+	VisibilityPublic  = "public"  // listed, anyone can join straight away
+	VisibilityPrivate = "private" // listed, joining needs an approved request
+	VisibilitySecret  = "secret"  // unlisted, invitation only
+)
+
+func validVisibility(v string) bool {
+	return v == VisibilityPublic || v == VisibilityPrivate || v == VisibilitySecret
+}
+
+// GetGroupVisibility returns the visibility setting of groupID
+func GetGroupVisibility(groupID int) (string, error) {
+	var visibility string
+	err := database.DB.QueryRow(queries.GetGroupVisibilityQuery, groupID).Scan(&visibility)
+	return visibility, err
+}
+
+// GetGroupDirectory lists the groups the user can discover. Secret groups
+// only show up for their members.
+//
+// Query parameters: q searches title and description, sort is "members"
+// (default) or "activity", and the returned nextCursor is passed back as
+// cursor to load the next page.
+func GetGroupDirectory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	search := strings.TrimSpace(r.URL.Query().Get("q"))
+	pattern := "%" + escapeLike(search) + "%"
+
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = "members"
+	}
+	if sort != "members" && sort != "activity" {
+		http.Error(w, "sort must be members or activity", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	cursorValue, cursorID, err := decodeDirectoryCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		return
+	}
+
+	query := queries.GetGroupDirectoryByMembersQuery
+	var cursorArg interface{} = 0
+	if sort == "activity" {
+		query = queries.GetGroupDirectoryByActivityQuery
+		cursorArg = cursorValue
+	} else if cursorValue != "" {
+		cursorArg, err = strconv.Atoi(cursorValue)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rows, err := database.DB.Query(query,
+		userID, search, pattern, pattern,
+		cursorID, cursorArg, cursorArg, cursorID,
+		limit+1,
+	)
+	if err != nil {
+		http.Error(w, "Failed to load groups", http.StatusInternalServerError)
+		fmt.Println("Error querying group directory in GetGroupDirectory:", err)
+		return
+	}
+	defer rows.Close()
+
+	groups := []models.GroupListing{}
+	for rows.Next() {
+		var g models.GroupListing
+		err := rows.Scan(
+			&g.ID, &g.Title, &g.Description, &g.Image, &g.CreatorID, &g.Visibility,
+			&g.MemberCount, &g.LastActivity, &g.IsMember,
+		)
+		if err != nil {
+			http.Error(w, "Failed to load groups", http.StatusInternalServerError)
+			fmt.Println("Scan error in GetGroupDirectory:", err)
+			return
+		}
+		groups = append(groups, g)
+	}
+
+	hasMore := len(groups) > limit
+	if hasMore {
+		groups = groups[:limit]
+	}
+
+	nextCursor := ""
+	if hasMore {
+		last := groups[len(groups)-1]
+		if sort == "activity" {
+			nextCursor = encodeDirectoryCursor(last.LastActivity, last.ID)
+		} else {
+			nextCursor = encodeDirectoryCursor(strconv.Itoa(last.MemberCount), last.ID)
+		}
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"groups":     groups,
+		"nextCursor": nextCursor,
+		"hasMore":    hasMore,
+	})
+}
+
+// escapeLike escapes the LIKE wildcards in s so it is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Directory cursors hold the sort value and ID of the last group on a page
+func encodeDirectoryCursor(value string, id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(value + "|" + strconv.Itoa(id)))
+}
+
+func decodeDirectoryCursor(cursor string) (string, int, error) {
+	if cursor == "" {
+		return "", 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, err
+	}
+	sep := strings.LastIndex(string(raw), "|")
+	if sep < 0 {
+		return "", 0, fmt.Errorf("malformed cursor")
+	}
+	id, err := strconv.Atoi(string(raw[sep+1:]))
+	if err != nil {
+		return "", 0, err
+	}
+	return string(raw[:sep]), id, nil
+}
@@ -0,0 +1,131 @@
+package groups
+
+import (
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/queries"
+	"social-network/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Highest age restriction an event can have; 0 means no restriction
+const maxRestrictedAge = 99
+
+// eventTimeLayout is how event times are stored, matching SQLite's datetime()
+const eventTimeLayout = "2006-01-02 15:04:05"
+
+// Accepted event time formats; times without an offset are taken as UTC
+var eventTimeInputLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	eventTimeLayout,
+	"2006-01-02 15:04",
+}
+
+// fieldErrors maps a form field to what is wrong with it
+type fieldErrors map[string]string
+
+func writeFieldErrors(w http.ResponseWriter, errs fieldErrors) {
+	utils.SendJSONResponse(w, http.StatusBadRequest, map[string]interface{}{
+		"error":  "validation_failed",
+		"fields": errs,
+	})
+}
+
+// parseEventTime reads an event time sent by the client and returns it in UTC
+func parseEventTime(value string) (time.Time, bool) {
+	for _, layout := range eventTimeInputLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// validateEventTime checks a datetime form value and returns it in storage format
+func validateEventTime(value string, errs fieldErrors) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		errs["datetime"] = "is required"
+		return ""
+	}
+	t, ok := parseEventTime(value)
+	if !ok {
+		errs["datetime"] = "must be a date and time such as 2030-01-31T18:00"
+		return ""
+	}
+	if !t.After(time.Now()) {
+		errs["datetime"] = "must be in the future"
+		return ""
+	}
+	return t.Format(eventTimeLayout)
+}
+
+// validateRestrictedAge checks a restrictedAge form value; empty means no restriction
+func validateRestrictedAge(value string, errs fieldErrors) int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	age, err := strconv.Atoi(value)
+	if err != nil {
+		errs["restrictedAge"] = "must be a whole number"
+		return 0
+	}
+	if age < 0 || age > maxRestrictedAge {
+		errs["restrictedAge"] = "must be between 0 and " + strconv.Itoa(maxRestrictedAge)
+		return 0
+	}
+	return age
+}
+
+// GetUserAge returns how old userID is today according to their date of birth
+func GetUserAge(userID int) (int, error) {
+	var dob string
+	err := database.DB.QueryRow(queries.GetUserDateOfBirthQuery, userID).Scan(&dob)
+	if err != nil {
+		return 0, err
+	}
+	birth, err := time.Parse("2006-01-02", dob)
+	if err != nil {
+		return 0, err
+	}
+	return ageAt(birth, time.Now()), nil
+}
+
+// ageAt returns the age in whole years of someone born on birth at time t
+func ageAt(birth, t time.Time) int {
+	age := t.Year() - birth.Year()
+	if t.Month() < birth.Month() || (t.Month() == birth.Month() && t.Day() < birth.Day()) {
+		age--
+	}
+	return age
+}
+
+// meetsAgeRestriction reports whether someone of userAge may see an event restricted to restriction
+func meetsAgeRestriction(userAge int, restriction string) bool {
+	minAge, _ := strconv.Atoi(restriction)
+	return userAge >= minAge
+}
+
+// requireEventAge replies 403 with the required age unless userID is old enough for the event
+func requireEventAge(w http.ResponseWriter, userID int, restriction string) bool {
+	age, err := GetUserAge(userID)
+	if err != nil {
+		http.Error(w, "Could not determine your age", http.StatusInternalServerError)
+		return false
+	}
+	if !meetsAgeRestriction(age, restriction) {
+		minAge, _ := strconv.Atoi(restriction)
+		utils.SendJSONResponse(w, http.StatusForbidden, map[string]interface{}{
+			"error":   "age_restricted",
+			"min_age": minAge,
+		})
+		return false
+	}
+	return true
+}
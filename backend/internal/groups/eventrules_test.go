@@ -0,0 +1,69 @@
+package groups
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseEventTime(t *testing.T) {
+	want := time.Date(2030, 1, 2, 13, 4, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+		ok    bool
+	}{
+		{"offset-less datetime-local value is UTC", "2030-01-02T13:04", want, true},
+		{"offset-less with seconds", "2030-01-02T13:04:00", want, true},
+		{"storage format", "2030-01-02 13:04:00", want, true},
+		{"UTC instant from toISOString", "2030-01-02T13:04:00.000Z", want, true},
+		{"positive offset", "2030-01-02T15:04:00+02:00", want, true},
+		{"offset without seconds", "2030-01-02T08:04-05:00", want, true},
+		{"date only", "2030-01-02", time.Time{}, false},
+		{"not a time", "tomorrow", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseEventTime(tt.value)
+			if ok != tt.ok {
+				t.Fatalf("parseEventTime(%q) ok = %v, want %v", tt.value, ok, tt.ok)
+			}
+			if !got.Equal(tt.want) || (ok && got.Location() != time.UTC) {
+				t.Errorf("parseEventTime(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateEventTime(t *testing.T) {
+	future := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Minute)
+	past := time.Now().UTC().Add(-48 * time.Hour)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"offset-less future time", future.Format("2006-01-02T15:04"), future.Format(eventTimeLayout), false},
+		{"future instant with offset", future.In(time.FixedZone("", 3*3600)).Format(time.RFC3339), future.Format(eventTimeLayout), false},
+		{"offset-less past time", past.Format("2006-01-02T15:04"), "", true},
+		{"empty", " ", "", true},
+		{"unreadable", "31/01/2030 18:00", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := fieldErrors{}
+			got := validateEventTime(tt.value, errs)
+			if _, hasErr := errs["datetime"]; hasErr != tt.wantErr {
+				t.Fatalf("validateEventTime(%q) errors = %v, want error %v", tt.value, errs, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("validateEventTime(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,27 +2,46 @@ package groups
 
 import (
 	"fmt"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"social-network/internal/database"
 	"social-network/internal/models"
 	"social-network/internal/queries"
 	"social-network/internal/utils"
-
-	"github.com/gofrs/uuid"
+	"strconv"
+	"strings"
 )
 
 func CreateGroup(w http.ResponseWriter, r *http.Request) {
-	err := r.ParseMultipartForm(10 << 20)
+	creator_id, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := utils.ParseUploadForm(w, r)
 	if err != nil {
 		http.Error(w, "Could not parse multipart form", http.StatusBadRequest)
 		return
 	}
-	title := r.FormValue("title")
+	if !claimedUserMatches(r.FormValue("creator_id"), creator_id) {
+		http.Error(w, "Cannot create a group for another user", http.StatusForbidden)
+		return
+	}
+
+	title := strings.TrimSpace(r.FormValue("title"))
 	description := r.FormValue("description")
-	creator_id := r.FormValue("creator_id")
+	if title == "" {
+		http.Error(w, "Title is required", http.StatusBadRequest)
+		return
+	}
+
+	visibility := r.FormValue("visibility")
+	if visibility == "" {
+		visibility = VisibilityPrivate
+	}
+	if !validVisibility(visibility) {
+		http.Error(w, "Visibility must be public, private or secret", http.StatusBadRequest)
+		return
+	}
 
 	var filename string
 
@@ -31,24 +50,10 @@ func CreateGroup(w http.ResponseWriter, r *http.Request) {
 	if err == nil {
 
 		defer file.Close()
-		// Create unique filename
-		ext := filepath.Ext(header.Filename)
-		filename = uuid.Must(uuid.NewV4()).String() + ext
-		savedPath := "./uploads/" + filename
 
-		// Create file
-		savedImage, err := os.Create(savedPath)
+		filename, err = utils.SaveUpload(file, header)
 		if err != nil {
-			fmt.Println(err, "in registration - creating file")
-			http.Error(w, "Could not save file", http.StatusInternalServerError)
-			return
-		}
-		defer savedImage.Close()
-
-		// Copy file content
-		_, err = io.Copy(savedImage, file)
-		if err != nil {
-			fmt.Println(err, "in registration - copying file")
+			fmt.Println(err, "in CreateGroup - saving file")
 			http.Error(w, "Could not save file", http.StatusInternalServerError)
 			return
 		}
@@ -59,7 +64,7 @@ func CreateGroup(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("No profile image uploaded during registration")
 	}
 
-	result, err := database.DB.Exec(queries.InsertGroupQuery, title, description, creator_id, filename)
+	result, err := database.DB.Exec(queries.InsertGroupQuery, title, description, creator_id, filename, visibility)
 	if err != nil {
 		http.Error(w, "Failed to insert group", http.StatusBadRequest)
 		fmt.Println("Error executing InsertGroupQuery in CreateGroup:", err)
@@ -73,7 +78,7 @@ func CreateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = database.DB.Exec(queries.InsertGroupMemberQuery, creator_id, groupID)
+	_, err = database.DB.Exec(queries.InsertGroupOwnerQuery, creator_id, groupID)
 	if err != nil {
 		http.Error(w, "Failed to insert group admin", http.StatusInternalServerError)
 		fmt.Println("Error inserting creator into group_members in CreateGroup:", err)
@@ -87,10 +92,12 @@ func CreateGroup(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetUserGroups(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "Missing user_id", http.StatusBadRequest)
-		fmt.Println("Missing user_id in GetUserGroups")
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	if !claimedUserMatches(r.URL.Query().Get("user_id"), userID) {
+		http.Error(w, "Cannot view another user's groups", http.StatusForbidden)
 		return
 	}
 
@@ -105,7 +112,7 @@ func GetUserGroups(w http.ResponseWriter, r *http.Request) {
 	var groups []models.GroupDetails
 	for rows.Next() {
 		var g models.GroupDetails
-		if err := rows.Scan(&g.ID, &g.Title, &g.Description, &g.Image); err != nil {
+		if err := rows.Scan(&g.ID, &g.Title, &g.Description, &g.Image, &g.Role); err != nil {
 			http.Error(w, "Error scanning group", http.StatusInternalServerError)
 			fmt.Println("Error scanning row in GetUserGroups:", err)
 			return
@@ -117,25 +124,30 @@ func GetUserGroups(w http.ResponseWriter, r *http.Request) {
 }
 
 func SearchUsers(w http.ResponseWriter, r *http.Request) {
-	searchquery := r.URL.Query().Get("q")
-	userquery := r.URL.Query().Get("user_id")
-	groupquery := r.URL.Query().Get("group_id")
+	userquery, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
 
+	searchquery := r.URL.Query().Get("q")
 	if searchquery == "" {
 		http.Error(w, "Missing search query", http.StatusBadRequest)
 		fmt.Println("Missing search query in SearchUsers")
 		return
 	}
-	if userquery == "" {
-		http.Error(w, "Missing user_id query param", http.StatusBadRequest)
-		fmt.Println("Missing user_id in SearchUsers")
+	if !claimedUserMatches(r.URL.Query().Get("user_id"), userquery) {
+		http.Error(w, "Cannot search as another user", http.StatusForbidden)
 		return
 	}
-	if groupquery == "" {
+	groupquery, err := strconv.Atoi(r.URL.Query().Get("group_id"))
+	if err != nil {
 		http.Error(w, "Missing group_id query param", http.StatusBadRequest)
 		fmt.Println("Missing group_id in SearchUsers")
 		return
 	}
+	if !requireMember(w, userquery, groupquery) {
+		return
+	}
 
 	searchPattern := "%" + searchquery + "%"
 
@@ -1,16 +1,23 @@
 package groups
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"social-network/internal/database"
 	"social-network/internal/models"
+	"social-network/internal/notifications"
 	"social-network/internal/queries"
 	"social-network/internal/utils"
 )
 
 func GroupInvitation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
 	var invitation models.Invitation
 
 	err := json.NewDecoder(r.Body).Decode(&invitation)
@@ -20,21 +27,99 @@ func GroupInvitation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err2 := database.DB.Exec(queries.InsertInvitationQuery, invitation.GroupID, invitation.TargetedID, invitation.InviterID)
+	if invitation.InviterID != 0 && invitation.InviterID != userID {
+		http.Error(w, "Cannot invite on behalf of another user", http.StatusForbidden)
+		return
+	}
+	invitation.InviterID = userID
+
+	if _, ok := requirePermission(w, userID, invitation.GroupID, PermInvite); !ok {
+		return
+	}
+	if rejectBanned(w, invitation.TargetedID, invitation.GroupID) {
+		return
+	}
+
+	isMember, err := IsGroupMember(invitation.TargetedID, invitation.GroupID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if isMember {
+		http.Error(w, "User is already a member of this group", http.StatusConflict)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := expireStale(tx); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Someone who already asked to join only needs an invitation to get in
+	requestID, err := pendingID(tx, queries.GetPendingJoinRequestIDQuery, invitation.GroupID, invitation.TargetedID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if requestID != 0 {
+		if err := addMember(tx, invitation.TargetedID, invitation.GroupID); err != nil {
+			http.Error(w, "Failed to add user to group", http.StatusInternalServerError)
+			fmt.Println("Error adding member in GroupInvitation:", err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Could not save changes", http.StatusInternalServerError)
+			return
+		}
+		utils.SendJSONResponse(w, http.StatusOK, map[string]string{
+			"message": "User had asked to join and was added to the group",
+			"status":  StatusAccepted,
+		})
+		return
+	}
+
+	result, err2 := tx.Exec(queries.InsertInvitationQuery, invitation.GroupID, invitation.TargetedID, invitation.InviterID)
+	if database.IsUniqueViolation(err2) {
+		http.Error(w, "User already has a pending invitation to this group", http.StatusConflict)
+		return
+	}
 	if err2 != nil {
-		http.Error(w, "Database error: "+err2.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to save invitation", http.StatusInternalServerError)
 		fmt.Println("Error executing InsertInvitationQuery in GroupInvitation:", err2)
 		return
 	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Could not save changes", http.StatusInternalServerError)
+		return
+	}
 
-	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "Invitation successful"})
+	invitationID, _ := result.LastInsertId()
+	err = notifications.Notify(invitation.TargetedID, invitation.InviterID, notifications.TypeGroupInvitation, invitation.GroupID, int(invitationID))
+	if err != nil {
+		fmt.Println("Error creating invitation notification in GroupInvitation:", err)
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"message":       "Invitation successful",
+		"status":        StatusPending,
+		"invitation_id": invitationID,
+	})
 }
 
 func  GetUserInvitations(w http.ResponseWriter, r *http.Request) {
-	userquery := r.URL.Query().Get("user_id")
-	if userquery == "" {
-		http.Error(w, "Missing user_id parameter", http.StatusBadRequest)
-		fmt.Println("Missing user_id in GetUserInvitations")
+	userquery, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	if !claimedUserMatches(r.URL.Query().Get("user_id"), userquery) {
+		http.Error(w, "Cannot view another user's invitations", http.StatusForbidden)
 		return
 	}
 
@@ -60,6 +145,11 @@ func  GetUserInvitations(w http.ResponseWriter, r *http.Request) {
 }
 
 func  InvitationResponse(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
 	var res models.Response
 	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -67,75 +157,222 @@ func  InvitationResponse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if res.Action != "accept" && res.Action != "decline" {
+	if res.UserID != 0 && res.UserID != userID {
+		http.Error(w, "Cannot respond for another user", http.StatusForbidden)
+		return
+	}
+
+	// The group and invitee come from the invitation itself, not the request body
+	var invitedUserID int
+	err := database.DB.QueryRow(queries.GetInvitationQuery, res.InvitationID).Scan(&res.GroupID, &invitedUserID)
+	if err != nil {
+		http.Error(w, "Invitation not found", http.StatusNotFound)
+		return
+	}
+	if invitedUserID != userID {
+		http.Error(w, "This invitation is not addressed to you", http.StatusForbidden)
+		return
+	}
+	res.UserID = userID
+
+	status, ok := responseStatus(res.Action)
+	if !ok {
 		http.Error(w, "Invalid action", http.StatusBadRequest)
 		fmt.Println("Invalid action value in InvitationResponse:", res.Action)
 		return
 	}
+	if status == StatusAccepted && rejectBanned(w, res.UserID, res.GroupID) {
+		return
+	}
 
-	_, err := database.DB.Exec(queries.UpdateInvitationQuery, res.Action, res.InvitationID)
+	tx, err := database.DB.Begin()
 	if err != nil {
-		http.Error(w, "Failed to update invitation", http.StatusInternalServerError)
-		fmt.Println("Error executing UpdateInvitationQuery in InvitationResponse:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
+	defer tx.Rollback()
 
-	if res.Action == "accept" {
-		fmt.Println(res.UserID)
-		_, err = database.DB.Exec(queries.InsertGroupMemberQuery, res.UserID, res.GroupID)
-		if err != nil {
-			http.Error(w, "Failed to add user to group", http.StatusInternalServerError)
-			fmt.Println("Error executing InsertGroupMemberQuery in InvitationResponse:", err)
-			return
-		}
+	err = expireStale(tx)
+	if err == nil {
+		err = resolve(tx, queries.ResolveInvitationQuery, res.InvitationID, status)
+	}
+	if err == nil && status == StatusAccepted {
+		err = addMember(tx, res.UserID, res.GroupID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		writeTransitionError(w, err, "invitation")
+		return
 	}
 
 	utils.SendJSONResponse(w, http.StatusOK, map[string]string{
 		"message": "Action processed successfully",
+		"status":  status,
 	})
 }
 
 func  RequestResponse(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
 	var res models.Response
 	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		fmt.Println("Error decoding body in InvitationResponse:", err)
+		fmt.Println("Error decoding body in RequestResponse:", err)
+		return
+	}
+
+	// The requester and group come from the stored request; only owners and admins may answer
+	err := database.DB.QueryRow(queries.GetJoinRequestQuery, res.InvitationID).Scan(&res.UserID, &res.GroupID)
+	if err != nil {
+		http.Error(w, "Join request not found", http.StatusNotFound)
+		return
+	}
+	if _, ok := requirePermission(w, userID, res.GroupID, PermApproveRequests); !ok {
 		return
 	}
 
-	if res.Action != "accept" && res.Action != "decline" {
+	status, ok := responseStatus(res.Action)
+	if !ok {
 		http.Error(w, "Invalid action", http.StatusBadRequest)
-		fmt.Println("Invalid action value in InvitationResponse:", res.Action)
+		fmt.Println("Invalid action value in RequestResponse:", res.Action)
+		return
+	}
+	if status == StatusAccepted && rejectBanned(w, res.UserID, res.GroupID) {
 		return
 	}
 
-	_, err := database.DB.Exec(queries.UpdateRequestQuery, res.Action, res.InvitationID)
+	tx, err := database.DB.Begin()
 	if err != nil {
-		http.Error(w, "Failed to update invitation", http.StatusInternalServerError)
-		fmt.Println("Error executing UpdateInvitationQuery in InvitationResponse:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
+	defer tx.Rollback()
 
-	if res.Action == "accept" {
-		fmt.Println(res.UserID)
-		_, err = database.DB.Exec(queries.InsertGroupMemberQuery, res.UserID, res.GroupID)
-		if err != nil {
-			http.Error(w, "Failed to add user to group", http.StatusInternalServerError)
-			fmt.Println("Error executing InsertGroupMemberQuery in InvitationResponse:", err)
-			return
-		}
+	err = expireStale(tx)
+	if err == nil {
+		err = resolve(tx, queries.ResolveJoinRequestQuery, res.InvitationID, status)
+	}
+	if err == nil && status == StatusAccepted {
+		err = addMember(tx, res.UserID, res.GroupID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		writeTransitionError(w, err, "join request")
+		return
 	}
 
 	utils.SendJSONResponse(w, http.StatusOK, map[string]string{
 		"message": "Action processed successfully",
+		"status":  status,
+	})
+}
+
+// CancelInvitation withdraws a pending invitation; only the user who sent it can
+func CancelInvitation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var res models.Response
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var senderID int
+	err := database.DB.QueryRow(queries.GetInvitationSenderQuery, res.InvitationID).Scan(&senderID)
+	if err != nil {
+		http.Error(w, "Invitation not found", http.StatusNotFound)
+		return
+	}
+	if senderID != userID {
+		http.Error(w, "Only the sender can cancel this invitation", http.StatusForbidden)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	err = resolve(tx, queries.ResolveInvitationQuery, res.InvitationID, StatusCancelled)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		writeTransitionError(w, err, "invitation")
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{
+		"message": "Invitation cancelled",
+		"status":  StatusCancelled,
+	})
+}
+
+// CancelJoinRequest withdraws the current user's pending request to join a group
+func CancelJoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var res models.Response
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var requesterID, groupID int
+	err := database.DB.QueryRow(queries.GetJoinRequestQuery, res.InvitationID).Scan(&requesterID, &groupID)
+	if err != nil {
+		http.Error(w, "Join request not found", http.StatusNotFound)
+		return
+	}
+	if requesterID != userID {
+		http.Error(w, "Only the requester can cancel this join request", http.StatusForbidden)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	err = resolve(tx, queries.ResolveJoinRequestQuery, res.InvitationID, StatusCancelled)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		writeTransitionError(w, err, "join request")
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{
+		"message": "Join request cancelled",
+		"status":  StatusCancelled,
 	})
 }
 
 func  GetPublicGroupsHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("user")
-	if userID == "" {
-		http.Error(w, "Missing user ID", http.StatusBadRequest)
-		fmt.Println("failed 1")
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	if !claimedUserMatches(r.URL.Query().Get("user"), userID) {
+		http.Error(w, "Cannot list groups for another user", http.StatusForbidden)
 		return
 	}
 	rows, err := database.DB.Query(queries.GetPublicGroupQuery, userID, userID)
@@ -161,25 +398,127 @@ func  GetPublicGroupsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func InsertGroupRequests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
 	var request models.GroupRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		fmt.Println(err, "in GetGroupRequests")
 		return
 	}
-	_, err := database.DB.Exec(queries.InsertGroupRequestQuery, request.UserID, request.GroupID)
+
+	if request.UserID != 0 && request.UserID != userID {
+		http.Error(w, "Cannot request to join for another user", http.StatusForbidden)
+		return
+	}
+	request.UserID = userID
+	if rejectBanned(w, request.UserID, request.GroupID) {
+		return
+	}
+
+	visibility, err := GetGroupVisibility(request.GroupID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	isMember, err := IsGroupMember(request.UserID, request.GroupID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if isMember {
+		http.Error(w, "You are already a member of this group", http.StatusConflict)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := expireStale(tx); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// An invited user asking to join simply takes up the invitation
+	invitationID, err := pendingID(tx, queries.GetPendingInvitationIDQuery, request.GroupID, request.UserID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if invitationID == 0 && visibility == VisibilitySecret {
+		http.Error(w, "This group is invitation only", http.StatusForbidden)
+		return
+	}
+
+	// Public groups need no approval
+	if invitationID != 0 || visibility == VisibilityPublic {
+		err := addMember(tx, request.UserID, request.GroupID)
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			http.Error(w, "Failed to join group", http.StatusInternalServerError)
+			fmt.Println(err, "in RequestJoinGroup")
+			return
+		}
+		utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "joined", "status": StatusAccepted})
+		return
+	}
+
+	result, err := tx.Exec(queries.InsertGroupRequestQuery, request.UserID, request.GroupID)
+	if database.IsUniqueViolation(err) {
+		http.Error(w, "You already asked to join this group", http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to save join request", http.StatusInternalServerError)
 		fmt.Println(err, "in RequestJoinGroup")
 		return
 	}
-	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "success"})
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Could not save changes", http.StatusInternalServerError)
+		return
+	}
+
+	// Everyone who can approve the request is notified
+	requestID, _ := result.LastInsertId()
+	managerIDs, err := GetGroupManagerIDs(request.GroupID)
+	if err != nil {
+		fmt.Println(err, "loading group managers in RequestJoinGroup")
+	}
+	for _, managerID := range managerIDs {
+		err := notifications.Notify(managerID, request.UserID, notifications.TypeGroupJoinRequest, request.GroupID, int(requestID))
+		if err != nil {
+			fmt.Println(err, "creating join request notification in RequestJoinGroup")
+		}
+	}
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"message":    "success",
+		"status":     StatusPending,
+		"request_id": requestID,
+	})
 }
 
 func  GetJoinRequestsToCreator(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "Missing user_id", http.StatusBadRequest)
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	if !claimedUserMatches(r.URL.Query().Get("user_id"), userID) {
+		http.Error(w, "Cannot view another user's join requests", http.StatusForbidden)
 		return
 	}
 
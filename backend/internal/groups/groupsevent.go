@@ -6,26 +6,73 @@ import (
 	"net/http"
 	"social-network/internal/database"
 	"social-network/internal/models"
+	"social-network/internal/notifications"
 	"social-network/internal/queries"
 	"social-network/internal/utils"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func CreateGroupEvent(w http.ResponseWriter, r *http.Request) {
-	err := r.ParseMultipartForm(10 << 20)
+	user_id, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := utils.ParseUploadForm(w, r)
 	if err != nil {
 		http.Error(w, "Could not parse multipart form", http.StatusBadRequest)
 		return
 	}
+	if !claimedUserMatches(r.FormValue("userid"), user_id) {
+		http.Error(w, "Cannot create an event for another user", http.StatusForbidden)
+		return
+	}
 
-	title := r.FormValue("title")
+	group_id, err := strconv.Atoi(r.FormValue("groupid"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+	if !requireMember(w, user_id, group_id) {
+		return
+	}
+
+	errs := fieldErrors{}
+	title := strings.TrimSpace(r.FormValue("title"))
+	if title == "" {
+		errs["title"] = "is required"
+	}
 	description := r.FormValue("description")
-	age := r.FormValue("restrictedAge")
-	dayTime := r.FormValue("datetime")
-	group_id := r.FormValue("groupid")
-	user_id := r.FormValue("userid")
+	age := validateRestrictedAge(r.FormValue("restrictedAge"), errs)
+	dayTime := validateEventTime(r.FormValue("datetime"), errs)
+	start, _ := parseEventTime(dayTime)
+	rec := validateRecurrence(r.FormValue("recurrence"), r.FormValue("recurrence_until"), r.FormValue("recurrence_count"), start, errs)
+
+	// The creator is marked as going, so they have to be old enough themselves
+	if _, failed := errs["restrictedAge"]; !failed && age > 0 {
+		creatorAge, err := GetUserAge(user_id)
+		if err != nil {
+			http.Error(w, "Could not determine your age", http.StatusInternalServerError)
+			return
+		}
+		if creatorAge < age {
+			errs["restrictedAge"] = "cannot be higher than your own age"
+		}
+	}
+	if len(errs) > 0 {
+		writeFieldErrors(w, errs)
+		return
+	}
+
+	if rec == nil {
+		rec = &models.Recurrence{}
+	}
 
 	// Insert event
-	result, err := database.DB.Exec(queries.InsertEventQuery, group_id, user_id, title, description, dayTime, age)
+	result, err := database.DB.Exec(queries.InsertEventQuery, group_id, user_id, title, description, dayTime, age,
+		rec.Frequency, rec.Until, rec.Count)
 	if err != nil {
 		http.Error(w, "Failed to insert event", http.StatusBadRequest)
 		fmt.Println("Insert error:", err)
@@ -39,7 +86,8 @@ func CreateGroupEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = database.DB.Exec(queries.EventResponseQuery, eventID, user_id, group_id, "going")
+	// For a recurring event this covers the first occurrence only
+	_, err = database.DB.Exec(queries.EventResponseQuery, eventID, user_id, group_id, 0, "going")
 
 	if err != nil {
 		http.Error(w, "Failed to mark user as going", http.StatusInternalServerError)
@@ -47,14 +95,50 @@ func CreateGroupEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	memberIDs, err := GetGroupMemberIDs(group_id)
+	if err != nil {
+		fmt.Println("Error loading group members for event notification:", err)
+	}
+	for _, memberID := range memberIDs {
+		// Members too young for the event cannot see it, so they are not told about it
+		if age > 0 {
+			memberAge, err := GetUserAge(memberID)
+			if err != nil {
+				fmt.Println("Error loading member age for event notification:", err)
+				continue
+			}
+			if !meetsAgeRestriction(memberAge, strconv.Itoa(age)) {
+				continue
+			}
+		}
+		err := notifications.Notify(memberID, user_id, notifications.TypeGroupEvent, group_id, int(eventID))
+		if err != nil {
+			fmt.Println("Error creating event notification:", err)
+		}
+	}
+
 	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "Event Created and Joined"})
 }
 
+// GetGroupEvents lists the occurrences in a group, each with the user's answer
+// if they gave one, optionally limited to a from/to window (see eventWindow)
 func GetGroupEvents(w http.ResponseWriter, r *http.Request) {
-	user_id := r.URL.Query().Get("user_id")
-	group_id := r.URL.Query().Get("group_id")
+	user_id, group_id, ok := eventListParams(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := eventWindow(w, r)
+	if !ok {
+		return
+	}
 
-	rows, err := database.DB.Query(queries.GetGroupEventsQuery, group_id, user_id)
+	age, err := GetUserAge(user_id)
+	if err != nil {
+		http.Error(w, "Could not determine your age", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := database.DB.Query(queries.GetGroupEventsQuery, group_id, age)
 	if err != nil {
 		http.Error(w, "Failed to query events", http.StatusInternalServerError)
 		fmt.Println("Get event query error:", err)
@@ -62,30 +146,65 @@ func GetGroupEvents(w http.ResponseWriter, r *http.Request) {
 	}
 	defer rows.Close()
 
-	var events []models.Event
-
+	var series []models.Event
 	for rows.Next() {
-		var ev models.Event
-		err := rows.Scan(
-			&ev.ID, &ev.GroupID, &ev.CreatorID,
-			&ev.Title, &ev.Description, &ev.Age,
-			&ev.EventTime,
-		)
+		ev, err := scanEvent(rows)
 		if err != nil {
 			http.Error(w, "Failed to scan event", http.StatusInternalServerError)
 			fmt.Println("Scan error:", err)
 			return
 		}
-		events = append(events, ev)
+		series = append(series, ev)
+	}
+
+	cancelled, err := loadOccurrenceSet(queries.GetGroupCancelledOccurrencesQuery, group_id)
+	var answers map[occurrenceKey]string
+	if err == nil {
+		answers, err = loadUserResponses(group_id, user_id)
+	}
+	if err != nil {
+		http.Error(w, "Failed to query events", http.StatusInternalServerError)
+		fmt.Println("Error loading event responses in GetGroupEvents:", err)
+		return
+	}
+
+	var events []models.Event
+	for _, ev := range series {
+		events = append(events, expandEvent(ev, from, to, cancelled)...)
+	}
+	for i := range events {
+		events[i].UserResponse = answers[occurrenceKey{events[i].ID, events[i].Occurrence}]
 	}
+	sortEventsByTime(events)
 
 	utils.SendJSONResponse(w, http.StatusOK, events)
 }
 
+// GetGoingEvents lists the occurrences in a group the user is going to,
+// optionally limited to a from/to window (see eventWindow)
 func GetGoingEvents(w http.ResponseWriter, r *http.Request) {
-	user_id := r.URL.Query().Get("user_id")
-	group_id := r.URL.Query().Get("group_id")
-	rows, err := database.DB.Query(queries.GetGoingEventQuery, group_id, user_id)
+	user_id, group_id, ok := eventListParams(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := eventWindow(w, r)
+	if !ok {
+		return
+	}
+	age, err := GetUserAge(user_id)
+	if err != nil {
+		http.Error(w, "Could not determine your age", http.StatusInternalServerError)
+		return
+	}
+
+	cancelled, err := loadOccurrenceSet(queries.GetGroupCancelledOccurrencesQuery, group_id)
+	if err != nil {
+		http.Error(w, "Failed to query events", http.StatusInternalServerError)
+		fmt.Println("Error loading cancelled occurrences in GetGoingEvents:", err)
+		return
+	}
+
+	rows, err := database.DB.Query(queries.GetGoingEventQuery, group_id, age, user_id)
 	if err != nil {
 		http.Error(w, "Failed to query events", http.StatusInternalServerError)
 		fmt.Println("Get event query error:", err)
@@ -96,25 +215,74 @@ func GetGoingEvents(w http.ResponseWriter, r *http.Request) {
 	var goingEvents []models.Event
 
 	for rows.Next() {
-		var ev models.Event
-		err := rows.Scan(
-			&ev.ID, &ev.GroupID, &ev.CreatorID,
-			&ev.Title, &ev.Description, &ev.Age,
-			&ev.EventTime,
-		)
+		var n int
+		ev, err := scanEvent(rows, &n)
 		if err != nil {
 			http.Error(w, "Failed to scan event", http.StatusInternalServerError)
 			fmt.Println("Scan error:", err)
 			return
 		}
-		goingEvents = append(goingEvents, ev)
+		occ, ok := eventOccurrence(ev, n)
+		if !ok || cancelled[occurrenceKey{ev.ID, n}] || !inEventWindow(occ.EventTime, from, to) {
+			continue
+		}
+		occ.UserResponse = "going"
+		goingEvents = append(goingEvents, occ)
 	}
+	sortEventsByTime(goingEvents)
 
 	utils.SendJSONResponse(w, http.StatusOK, goingEvents)
 
 }
 
+// loadUserResponses returns userID's answers to the events of groupID by occurrence
+func loadUserResponses(groupID, userID int) (map[occurrenceKey]string, error) {
+	rows, err := database.DB.Query(queries.GetUserGroupEventResponsesQuery, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	answers := map[occurrenceKey]string{}
+	for rows.Next() {
+		var key occurrenceKey
+		var response string
+		if err := rows.Scan(&key.EventID, &key.Occurrence, &response); err != nil {
+			return nil, err
+		}
+		answers[key] = response
+	}
+	return answers, rows.Err()
+}
+
+// eventListParams validates the session user and group_id for the event listings
+func eventListParams(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	user_id, ok := currentUser(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+	if !claimedUserMatches(r.URL.Query().Get("user_id"), user_id) {
+		http.Error(w, "Cannot view events for another user", http.StatusForbidden)
+		return 0, 0, false
+	}
+
+	group_id, err := strconv.Atoi(r.URL.Query().Get("group_id"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	if !requireMember(w, user_id, group_id) {
+		return 0, 0, false
+	}
+	return user_id, group_id, true
+}
+
 func EventResponse(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
 	var input models.EventResponseInput
 
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -123,17 +291,39 @@ func EventResponse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if input.Response != "going" && input.Response != "not_going" {
-		http.Error(w, "Response must be 'going' or 'not_going'", http.StatusBadRequest)
-		fmt.Println("only going ornot_going valid answers error")
+	if input.UserID != 0 && input.UserID != userID {
+		http.Error(w, "Cannot respond for another user", http.StatusForbidden)
+		return
+	}
+	input.UserID = userID
+
+	// The group comes from the event, not the request body
+	ev, err := loadEvent(input.EventID)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	input.GroupID = ev.GroupID
+	if !requireMember(w, userID, input.GroupID) {
+		return
+	}
+	if ev.Status == EventCancelled {
+		http.Error(w, "This event has been cancelled", http.StatusConflict)
+		return
+	}
+	if !requireEventAge(w, userID, ev.Age) {
+		return
+	}
+	if !requireOccurrence(w, ev, input.Occurrence) {
+		return
+	}
+
+	if input.Response != "going" && input.Response != "maybe" && input.Response != "not_going" {
+		http.Error(w, "Response must be 'going', 'maybe' or 'not_going'", http.StatusBadRequest)
 		return
 	}
 
-	
-	_, err := database.DB.Exec(`
-		INSERT INTO event_responses (event_id, user_id, group_id, response)
-		VALUES (?, ?, ?, ?)
-	`, input.EventID, input.UserID, input.GroupID, input.Response)
+	_, err = database.DB.Exec(queries.EventResponseQuery, input.EventID, input.UserID, input.GroupID, input.Occurrence, input.Response)
 	if err != nil {
 		http.Error(w, "Failed to save response", http.StatusInternalServerError)
 		fmt.Println("Insert error:", err)
@@ -141,6 +331,355 @@ func EventResponse(w http.ResponseWriter, r *http.Request) {
 	}
 
 	utils.SendJSONResponse(w, http.StatusOK, map[string]string{
-		"message": "Response recorded successfully",
+		"message":  "Response recorded successfully",
+		"response": input.Response,
+	})
+}
+
+// Event states
+const (
+	EventScheduled = "scheduled"
+	EventCancelled = "cancelled"
+)
+
+func loadEvent(eventID int) (models.Event, error) {
+	var status string
+	ev, err := scanEvent(database.DB.QueryRow(queries.GetEventQuery, eventID), &status)
+	ev.Status = status
+	return ev, err
+}
+
+// scanEvent reads the event columns shared by the event queries, then any extra columns into extra
+func scanEvent(row interface{ Scan(...interface{}) error }, extra ...interface{}) (models.Event, error) {
+	var ev models.Event
+	var rec models.Recurrence
+	dest := []interface{}{
+		&ev.ID, &ev.GroupID, &ev.CreatorID,
+		&ev.Title, &ev.Description, &ev.Age,
+		&ev.EventTime, &rec.Frequency, &rec.Until, &rec.Count,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return ev, err
+	}
+	if rec.Frequency != "" {
+		if until, ok := parseEventTime(rec.Until); ok {
+			rec.Until = until.Format(time.RFC3339)
+		}
+		ev.Recurrence = &rec
+	}
+	return ev, nil
+}
+
+// requireOccurrence replies with an error unless occurrence n of ev exists,
+// has not started yet and still takes place
+func requireOccurrence(w http.ResponseWriter, ev models.Event, n int) bool {
+	occ, ok := eventOccurrence(ev, n)
+	if !ok {
+		http.Error(w, "Occurrence not found", http.StatusNotFound)
+		return false
+	}
+	if start, _ := parseEventTime(occ.EventTime); !start.After(time.Now()) {
+		http.Error(w, "This occurrence has already started", http.StatusConflict)
+		return false
+	}
+	var cancelled bool
+	err := database.DB.QueryRow(queries.IsOccurrenceCancelledQuery, ev.ID, n).Scan(&cancelled)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		fmt.Println("Error executing IsOccurrenceCancelledQuery:", err)
+		return false
+	}
+	if cancelled {
+		http.Error(w, "This occurrence has been cancelled", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// requireEventManager replies 403 unless userID created the event or manages events in its group
+func requireEventManager(w http.ResponseWriter, userID int, ev models.Event) bool {
+	if ev.CreatorID == userID {
+		return true
+	}
+	_, ok := requirePermission(w, userID, ev.GroupID, PermManageEvents)
+	return ok
+}
+
+// allOccurrences makes notifyAttendees reach the attendees of every occurrence
+const allOccurrences = -1
+
+// notifyAttendees tells everyone going to (or maybe going to) an event, or one
+// occurrence of it, that it changed
+func notifyAttendees(ev models.Event, occurrence int, actorID int, notificationType string) {
+	query, args := queries.GetEventAttendeeIDsQuery, []interface{}{ev.ID}
+	if occurrence != allOccurrences {
+		query, args = queries.GetOccurrenceAttendeeIDsQuery, []interface{}{ev.ID, occurrence}
+	}
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		fmt.Println("Error loading event attendees:", err)
+		return
+	}
+	var attendeeIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err == nil {
+			attendeeIDs = append(attendeeIDs, id)
+		}
+	}
+	rows.Close()
+
+	for _, attendeeID := range attendeeIDs {
+		err := notifications.Notify(attendeeID, actorID, notificationType, ev.GroupID, ev.ID)
+		if err != nil {
+			fmt.Println("Error creating event notification:", err)
+		}
+	}
+}
+
+// GetEventAttendees lists who answered an event, with a count per answer.
+// For recurring events the occurrence query parameter picks the occurrence.
+func GetEventAttendees(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	eventID, err := strconv.Atoi(r.URL.Query().Get("event_id"))
+	if err != nil {
+		http.Error(w, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+	ev, err := loadEvent(eventID)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !requireMember(w, userID, ev.GroupID) {
+		return
+	}
+
+	// Age-restricted events are invisible to members who are too young
+	age, err := GetUserAge(userID)
+	if err != nil {
+		http.Error(w, "Could not determine your age", http.StatusInternalServerError)
+		return
+	}
+	if !meetsAgeRestriction(age, ev.Age) {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+
+	n, ok := occurrenceParam(w, r)
+	if !ok {
+		return
+	}
+	ev, ok = eventOccurrence(ev, n)
+	if !ok {
+		http.Error(w, "Occurrence not found", http.StatusNotFound)
+		return
+	}
+
+	rows, err := database.DB.Query(queries.GetEventAttendeesQuery, eventID, n)
+	if err != nil {
+		http.Error(w, "Failed to load attendees", http.StatusInternalServerError)
+		fmt.Println("Error executing GetEventAttendeesQuery:", err)
+		return
+	}
+	defer rows.Close()
+
+	attendees := []models.EventAttendee{}
+	counts := map[string]int{"going": 0, "maybe": 0, "not_going": 0}
+	for rows.Next() {
+		var a models.EventAttendee
+		err := rows.Scan(&a.ID, &a.Nickname, &a.FirstName, &a.LastName, &a.ProfilePic, &a.Response)
+		if err != nil {
+			http.Error(w, "Failed to load attendees", http.StatusInternalServerError)
+			fmt.Println("Scan error:", err)
+			return
+		}
+		a.ProfilePic = strings.Replace(a.ProfilePic, "./uploads/", "/uploads/", 1)
+		counts[a.Response]++
+		attendees = append(attendees, a)
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"event":     ev,
+		"counts":    counts,
+		"attendees": attendees,
+	})
+}
+
+// UpdateGroupEvent changes an event's details. It takes the same form fields
+// as CreateGroupEvent plus event_id; fields left out keep their value.
+func UpdateGroupEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := utils.ParseUploadForm(w, r)
+	if err != nil {
+		http.Error(w, "Could not parse multipart form", http.StatusBadRequest)
+		return
+	}
+
+	eventID, err := strconv.Atoi(r.FormValue("event_id"))
+	if err != nil {
+		http.Error(w, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+	ev, err := loadEvent(eventID)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !requireEventManager(w, userID, ev) {
+		return
+	}
+	if ev.Status == EventCancelled {
+		http.Error(w, "This event has been cancelled", http.StatusConflict)
+		return
+	}
+
+	errs := fieldErrors{}
+	form := r.MultipartForm.Value
+	if _, ok := form["title"]; ok {
+		ev.Title = strings.TrimSpace(r.FormValue("title"))
+		if ev.Title == "" {
+			errs["title"] = "is required"
+		}
+	}
+	if _, ok := form["description"]; ok {
+		ev.Description = r.FormValue("description")
+	}
+	if _, ok := form["datetime"]; ok {
+		ev.EventTime = validateEventTime(r.FormValue("datetime"), errs)
+		// Moving a series past its end would leave it without occurrences
+		if _, ok := eventOccurrence(ev, 0); ev.EventTime != "" && !ok {
+			errs["datetime"] = "must not be after the end of the recurrence"
+		}
+	}
+	if _, ok := form["restrictedAge"]; ok {
+		ev.Age = strconv.Itoa(validateRestrictedAge(r.FormValue("restrictedAge"), errs))
+	}
+	if len(errs) > 0 {
+		writeFieldErrors(w, errs)
+		return
+	}
+	// Times read back from the database come out as RFC 3339
+	if t, ok := parseEventTime(ev.EventTime); ok {
+		ev.EventTime = t.Format(eventTimeLayout)
+	}
+
+	_, err = database.DB.Exec(queries.UpdateEventQuery, ev.Title, ev.Description, ev.EventTime, ev.Age, ev.ID)
+	if err != nil {
+		http.Error(w, "Failed to update event", http.StatusBadRequest)
+		fmt.Println("Update event error:", err)
+		return
+	}
+
+	// Reminders are due again relative to the new time
+	if _, moved := form["datetime"]; moved {
+		if _, err := database.DB.Exec(queries.ClearEventRemindersQuery, ev.ID); err != nil {
+			fmt.Println("Error clearing event reminders:", err)
+		}
+	}
+
+	notifyAttendees(ev, allOccurrences, userID, notifications.TypeEventUpdated)
+
+	updated, err := loadEvent(ev.ID)
+	if err != nil {
+		http.Error(w, "Failed to load event", http.StatusInternalServerError)
+		return
+	}
+	utils.SendJSONResponse(w, http.StatusOK, updated)
+}
+
+// CancelGroupEvent cancels an event and lets its attendees know. With an
+// occurrence in the body only that occurrence of a recurring event is cancelled.
+func CancelGroupEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.EventCancelInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ev, err := loadEvent(input.EventID)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !requireEventManager(w, userID, ev) {
+		return
+	}
+	if input.Occurrence != nil {
+		cancelOccurrence(w, ev, *input.Occurrence, userID)
+		return
+	}
+
+	result, err := database.DB.Exec(queries.CancelEventQuery, ev.ID)
+	if err != nil {
+		http.Error(w, "Failed to cancel event", http.StatusInternalServerError)
+		fmt.Println("Cancel event error:", err)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		http.Error(w, "This event has already been cancelled", http.StatusConflict)
+		return
+	}
+
+	notifyAttendees(ev, allOccurrences, userID, notifications.TypeEventCancelled)
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "Event cancelled"})
+}
+
+// cancelOccurrence calls off occurrence n of ev while the rest of the series goes ahead
+func cancelOccurrence(w http.ResponseWriter, ev models.Event, n int, userID int) {
+	if ev.Status == EventCancelled {
+		http.Error(w, "This event has already been cancelled", http.StatusConflict)
+		return
+	}
+	if _, ok := eventOccurrence(ev, n); !ok {
+		http.Error(w, "Occurrence not found", http.StatusNotFound)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Failed to cancel occurrence", http.StatusInternalServerError)
+		fmt.Println("Error starting transaction in cancelOccurrence:", err)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(queries.CancelOccurrenceQuery, ev.ID, n, userID)
+	if database.IsUniqueViolation(err) {
+		http.Error(w, "This occurrence has already been cancelled", http.StatusConflict)
+		return
+	}
+	// Calendar apps only pick up the cancellation if the sequence moves on
+	if err == nil {
+		_, err = tx.Exec(queries.BumpEventSequenceQuery, ev.ID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		http.Error(w, "Failed to cancel occurrence", http.StatusInternalServerError)
+		fmt.Println("Cancel occurrence error:", err)
+		return
+	}
+
+	notifyAttendees(ev, n, userID, notifications.TypeEventCancelled)
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"message":    "Occurrence cancelled",
+		"occurrence": n,
 	})
 }
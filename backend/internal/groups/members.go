@@ -0,0 +1,106 @@
+package groups
+
+import (
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/queries"
+	"social-network/internal/sessions"
+	"strconv"
+)
+
+// RoomLeaver takes users out of live group chat rooms
+type RoomLeaver interface {
+	LeaveRoom(groupID, userID int)
+}
+
+var rooms RoomLeaver
+
+// SetRoomLeaver registers the live chat rooms, normally the WebSocket manager
+func SetRoomLeaver(r RoomLeaver) {
+	rooms = r
+}
+
+// leaveChatRoom disconnects userID from groupID's chat after they stop being a member
+func leaveChatRoom(groupID, userID int) {
+	if rooms != nil {
+		rooms.LeaveRoom(groupID, userID)
+	}
+}
+
+// currentUser returns the user resolved by sessions.RequireAuth, replying 401 if there is none
+func currentUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, _, ok := sessions.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	}
+	return userID, ok
+}
+
+// claimedUserMatches reports whether a user ID sent by the client is either
+// absent or the session user. Older clients still send their own ID.
+func claimedUserMatches(claimed string, userID int) bool {
+	if claimed == "" {
+		return true
+	}
+	id, err := strconv.Atoi(claimed)
+	return err == nil && id == userID
+}
+
+// requireMember replies 403 unless userID belongs to groupID
+func requireMember(w http.ResponseWriter, userID, groupID int) bool {
+	isMember, err := IsGroupMember(userID, groupID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+	if !isMember {
+		http.Error(w, "You are not a member of this group", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// IsGroupMember reports whether userID belongs to groupID
+func IsGroupMember(userID, groupID int) (bool, error) {
+	var isMember bool
+	err := database.DB.QueryRow(queries.IsGroupMemberQuery, userID, groupID).Scan(&isMember)
+	return isMember, err
+}
+
+// GetUserGroupIDs returns the IDs of every group userID is a member of
+func GetUserGroupIDs(userID int) ([]int, error) {
+	rows, err := database.DB.Query(queries.GetUserGroupIDsQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groupIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		groupIDs = append(groupIDs, id)
+	}
+	return groupIDs, rows.Err()
+}
+
+// GetGroupMemberIDs returns the user IDs of every member of groupID
+func GetGroupMemberIDs(groupID int) ([]int, error) {
+	rows, err := database.DB.Query(queries.GetGroupMemberIDsQuery, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs, rows.Err()
+}
@@ -0,0 +1,229 @@
+package groups
+
+import (
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How often a recurring event repeats
+const (
+	RecurDaily   = "daily"
+	RecurWeekly  = "weekly"
+	RecurMonthly = "monthly"
+)
+
+// Longest series an event can have, so expanding it always stays cheap
+const maxOccurrences = 500
+
+// occurrenceKey identifies one occurrence of an event
+type occurrenceKey struct {
+	EventID    int
+	Occurrence int
+}
+
+// validateRecurrence checks the recurrence form fields of an event starting at
+// start. It returns nil for events that do not repeat.
+func validateRecurrence(frequency, until, count string, start time.Time, errs fieldErrors) *models.Recurrence {
+	frequency = strings.TrimSpace(frequency)
+	until = strings.TrimSpace(until)
+	count = strings.TrimSpace(count)
+
+	if frequency == "" {
+		if until != "" || count != "" {
+			errs["recurrence"] = "is required when recurrence_until or recurrence_count is set"
+		}
+		return nil
+	}
+	if frequency != RecurDaily && frequency != RecurWeekly && frequency != RecurMonthly {
+		errs["recurrence"] = "must be daily, weekly or monthly"
+		return nil
+	}
+	if (until == "") == (count == "") {
+		errs["recurrence"] = "needs either recurrence_until or recurrence_count"
+		return nil
+	}
+
+	rec := &models.Recurrence{Frequency: frequency}
+	if count != "" {
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 1 || n > maxOccurrences {
+			errs["recurrence_count"] = "must be between 1 and " + strconv.Itoa(maxOccurrences)
+			return nil
+		}
+		rec.Count = n
+		return rec
+	}
+
+	end, ok := parseRecurrenceUntil(until)
+	if !ok {
+		errs["recurrence_until"] = "must be a date such as 2030-01-31"
+		return nil
+	}
+	if start.IsZero() {
+		return nil // the start time already has an error
+	}
+	if end.Before(start) {
+		errs["recurrence_until"] = "must not be before the event starts"
+		return nil
+	}
+	if !occurrenceStart(start, frequency, maxOccurrences).After(end) {
+		errs["recurrence_until"] = "allows at most " + strconv.Itoa(maxOccurrences) + " occurrences"
+		return nil
+	}
+	rec.Until = end.Format(eventTimeLayout)
+	return rec
+}
+
+// parseRecurrenceUntil reads the end of a series. A plain date includes that whole day.
+func parseRecurrenceUntil(value string) (time.Time, bool) {
+	if day, err := time.Parse("2006-01-02", value); err == nil {
+		return day.Add(24*time.Hour - time.Second), true
+	}
+	return parseEventTime(value)
+}
+
+// occurrenceStart returns when occurrence n of a series starting at start begins.
+// Monthly events on the 29th-31st fall on the last day of shorter months.
+func occurrenceStart(start time.Time, frequency string, n int) time.Time {
+	switch frequency {
+	case RecurDaily:
+		return start.AddDate(0, 0, n)
+	case RecurWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case RecurMonthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(n), 1,
+			start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+		day := start.Day()
+		if last := first.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		return first.AddDate(0, 0, day-1)
+	}
+	return start
+}
+
+// eventOccurrence returns occurrence n of ev, with EventTime set to when it
+// starts, or false if the series has no such occurrence
+func eventOccurrence(ev models.Event, n int) (models.Event, bool) {
+	start, ok := parseEventTime(ev.EventTime)
+	if !ok || n < 0 {
+		return ev, false
+	}
+	if ev.Recurrence == nil {
+		ev.Occurrence = 0
+		return ev, n == 0
+	}
+	if n >= maxOccurrences || (ev.Recurrence.Count > 0 && n >= ev.Recurrence.Count) {
+		return ev, false
+	}
+	t := occurrenceStart(start, ev.Recurrence.Frequency, n)
+	if ev.Recurrence.Until != "" {
+		if until, ok := parseEventTime(ev.Recurrence.Until); ok && t.After(until) {
+			return ev, false
+		}
+	}
+	ev.EventTime = t.Format(time.RFC3339)
+	ev.Occurrence = n
+	return ev, true
+}
+
+// expandEvent lists the occurrences of ev starting in [from, to), leaving out
+// cancelled ones. A zero from or to leaves that side of the window open.
+func expandEvent(ev models.Event, from, to time.Time, cancelled map[occurrenceKey]bool) []models.Event {
+	var events []models.Event
+	for n := 0; ; n++ {
+		occ, ok := eventOccurrence(ev, n)
+		if !ok {
+			break
+		}
+		t, _ := parseEventTime(occ.EventTime)
+		if !to.IsZero() && !t.Before(to) {
+			break
+		}
+		if t.Before(from) || cancelled[occurrenceKey{ev.ID, n}] {
+			continue
+		}
+		events = append(events, occ)
+	}
+	return events
+}
+
+// inEventWindow reports whether an occurrence starting at eventTime lies in [from, to)
+func inEventWindow(eventTime string, from, to time.Time) bool {
+	t, ok := parseEventTime(eventTime)
+	return ok && !t.Before(from) && (to.IsZero() || t.Before(to))
+}
+
+// sortEventsByTime orders occurrences by when they start
+func sortEventsByTime(events []models.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		a, _ := parseEventTime(events[i].EventTime)
+		b, _ := parseEventTime(events[j].EventTime)
+		return a.Before(b)
+	})
+}
+
+// loadOccurrenceSet runs a query returning (event_id, occurrence) pairs
+func loadOccurrenceSet(query string, args ...interface{}) (map[occurrenceKey]bool, error) {
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	set := map[occurrenceKey]bool{}
+	for rows.Next() {
+		var key occurrenceKey
+		if err := rows.Scan(&key.EventID, &key.Occurrence); err != nil {
+			return nil, err
+		}
+		set[key] = true
+	}
+	return set, rows.Err()
+}
+
+// eventWindow reads the optional from and to query parameters bounding which
+// occurrences are listed. Both take a date or a date and time; to is exclusive.
+// Without from only occurrences that have not started yet are listed.
+func eventWindow(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	bounds := [2]time.Time{time.Now()}
+	for i, name := range []string{"from", "to"} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			var ok bool
+			if t, ok = parseEventTime(value); !ok {
+				http.Error(w, "Invalid "+name+" date", http.StatusBadRequest)
+				return time.Time{}, time.Time{}, false
+			}
+		}
+		bounds[i] = t
+	}
+	if !bounds[1].IsZero() && !bounds[1].After(bounds[0]) {
+		http.Error(w, "to must be after from", http.StatusBadRequest)
+		return time.Time{}, time.Time{}, false
+	}
+	return bounds[0], bounds[1], true
+}
+
+// occurrenceParam reads the occurrence query parameter, which defaults to 0
+func occurrenceParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("occurrence")
+	if value == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		http.Error(w, "Invalid occurrence", http.StatusBadRequest)
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,87 @@
+package groups
+
+import (
+	"fmt"
+	"social-network/internal/database"
+	"social-network/internal/models"
+	"social-network/internal/notifications"
+	"social-network/internal/queries"
+	"time"
+)
+
+// reminderCandidate is one user going to one occurrence of an event
+type reminderCandidate struct {
+	event  models.Event
+	userID int
+}
+
+// SendEventReminders reminds everyone going to an event once it is less than
+// one of offsets away. It is run periodically from main; which reminders went
+// out is stored, so nothing is sent twice, even across restarts.
+func SendEventReminders(offsets []time.Duration) {
+	if len(offsets) == 0 {
+		return
+	}
+
+	rows, err := database.DB.Query(queries.GetReminderCandidatesQuery)
+	if err != nil {
+		fmt.Println("Error loading event reminders:", err)
+		return
+	}
+	// Read everything first; SQLite cannot commit the inserts below while this query is open
+	var candidates []reminderCandidate
+	for rows.Next() {
+		var n, userID int
+		ev, err := scanEvent(rows, &n, &userID)
+		if err != nil {
+			fmt.Println("Scan error in SendEventReminders:", err)
+			continue
+		}
+		if occ, ok := eventOccurrence(ev, n); ok {
+			candidates = append(candidates, reminderCandidate{occ, userID})
+		}
+	}
+	rows.Close()
+
+	now := time.Now()
+	for _, c := range candidates {
+		start, _ := parseEventTime(c.event.EventTime)
+		if !start.After(now) {
+			continue
+		}
+		sent, err := markRemindersDue(c, start, now, offsets)
+		if err != nil {
+			fmt.Println("Error storing event reminder:", err)
+			continue
+		}
+		if !sent {
+			continue
+		}
+		err = notifications.NotifyEventReminder(c.userID, c.event.CreatorID, c.event.GroupID, c.event.ID, c.event.Title, start.Sub(now))
+		if err != nil {
+			fmt.Println("Error sending event reminder:", err)
+		}
+	}
+}
+
+// markRemindersDue records every offset that is due for c and reports whether
+// any of them was new. Several offsets can fall due at once (after downtime, or
+// when someone answers shortly before the event); they lead to one reminder.
+// Recording before sending means a crash loses a reminder rather than repeating it.
+func markRemindersDue(c reminderCandidate, start, now time.Time, offsets []time.Duration) (bool, error) {
+	sent := false
+	for _, offset := range offsets {
+		if start.Add(-offset).After(now) {
+			continue
+		}
+		result, err := database.DB.Exec(queries.InsertEventReminderQuery,
+			c.event.ID, c.event.Occurrence, c.userID, int(offset/time.Second))
+		if err != nil {
+			return false, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			sent = true
+		}
+	}
+	return sent, nil
+}
@@ -0,0 +1,351 @@
+package groups
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/queries"
+	"social-network/internal/utils"
+)
+
+// Member roles, from most to least privileged
+const (
+	RoleOwner     = "owner"
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleMember    = "member"
+)
+
+var roleRank = map[string]int{
+	RoleMember:    1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+	RoleOwner:     4,
+}
+
+// Permission is an action restricted to some roles
+type Permission string
+
+const (
+	PermInvite          Permission = "invite"
+	PermApproveRequests Permission = "approve_requests"
+	PermRemoveMembers   Permission = "remove_members"
+	PermBanMembers      Permission = "ban_members"
+	PermDeletePosts     Permission = "delete_posts"
+	PermManageEvents    Permission = "manage_events"
+	PermManageRoles     Permission = "manage_roles"
+	PermEditGroup       Permission = "edit_group"
+)
+
+// Lowest role allowed to perform each permission
+var minimumRole = map[Permission]string{
+	PermInvite:          RoleMember,
+	PermApproveRequests: RoleAdmin,
+	PermRemoveMembers:   RoleAdmin,
+	PermBanMembers:      RoleAdmin,
+	PermDeletePosts:     RoleModerator,
+	PermManageEvents:    RoleAdmin,
+	PermManageRoles:     RoleAdmin,
+	PermEditGroup:       RoleAdmin,
+}
+
+// HasPermission reports whether role may perform perm
+func HasPermission(role string, perm Permission) bool {
+	return roleRank[role] > 0 && roleRank[role] >= roleRank[minimumRole[perm]]
+}
+
+// outranks reports whether role a is strictly above role b
+func outranks(a, b string) bool {
+	return roleRank[a] > roleRank[b]
+}
+
+// GetMemberRole returns userID's role in groupID, or "" if they are not a member
+func GetMemberRole(userID, groupID int) (string, error) {
+	var role string
+	err := database.DB.QueryRow(queries.GetMemberRoleQuery, userID, groupID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// requirePermission replies 403 unless userID holds perm in groupID and returns their role
+func requirePermission(w http.ResponseWriter, userID, groupID int, perm Permission) (string, bool) {
+	role, err := GetMemberRole(userID, groupID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return "", false
+	}
+	if role == "" {
+		http.Error(w, "You are not a member of this group", http.StatusForbidden)
+		return "", false
+	}
+	if !HasPermission(role, perm) {
+		http.Error(w, "You do not have permission to do that in this group", http.StatusForbidden)
+		return "", false
+	}
+	return role, true
+}
+
+// GetGroupManagerIDs returns the owner and admins of groupID
+func GetGroupManagerIDs(groupID int) ([]int, error) {
+	rows, err := database.DB.Query(queries.GetGroupManagerIDsQuery, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs, rows.Err()
+}
+
+type memberRequest struct {
+	GroupID int    `json:"group_id"`
+	UserID  int    `json:"user_id"`
+	Role    string `json:"role"`
+}
+
+// UpdateMemberRole promotes or demotes a member. Admins manage moderators and
+// members; only the owner can make or unmake admins.
+func UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req memberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Role != RoleAdmin && req.Role != RoleModerator && req.Role != RoleMember {
+		http.Error(w, "Role must be admin, moderator or member", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == userID {
+		http.Error(w, "You cannot change your own role", http.StatusBadRequest)
+		return
+	}
+
+	actorRole, ok := requirePermission(w, userID, req.GroupID, PermManageRoles)
+	if !ok {
+		return
+	}
+
+	targetRole, err := GetMemberRole(req.UserID, req.GroupID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		fmt.Println("Error getting member role in UpdateMemberRole:", err)
+		return
+	}
+	if targetRole == "" {
+		http.Error(w, "User is not a member of this group", http.StatusNotFound)
+		return
+	}
+
+	// Both the member's current and new role must be below the actor's
+	if !outranks(actorRole, targetRole) || !outranks(actorRole, req.Role) {
+		http.Error(w, "You cannot assign that role", http.StatusForbidden)
+		return
+	}
+
+	_, err = database.DB.Exec(queries.UpdateMemberRoleQuery, req.Role, req.UserID, req.GroupID)
+	if err != nil {
+		http.Error(w, "Failed to update role", http.StatusInternalServerError)
+		fmt.Println("Error executing UpdateMemberRoleQuery in UpdateMemberRole:", err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{
+		"message": "Role updated",
+		"role":    req.Role,
+	})
+}
+
+// TransferOwnership hands the group to another member; the old owner becomes an admin
+func TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req memberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	role, err := GetMemberRole(userID, req.GroupID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if role != RoleOwner {
+		http.Error(w, "Only the owner can transfer ownership", http.StatusForbidden)
+		return
+	}
+
+	targetRole, err := GetMemberRole(req.UserID, req.GroupID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if targetRole == "" || req.UserID == userID {
+		http.Error(w, "New owner must be another member of the group", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := transferOwnership(tx, req.GroupID, userID, req.UserID); err != nil {
+		http.Error(w, "Failed to transfer ownership", http.StatusInternalServerError)
+		fmt.Println("Error transferring ownership in TransferOwnership:", err)
+		return
+	}
+	if _, err := tx.Exec(queries.UpdateMemberRoleQuery, RoleAdmin, userID, req.GroupID); err != nil {
+		http.Error(w, "Failed to transfer ownership", http.StatusInternalServerError)
+		fmt.Println("Error demoting old owner in TransferOwnership:", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Could not save changes", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "Ownership transferred"})
+}
+
+// transferOwnership makes newOwnerID the owner and creator of record. The
+// caller decides what happens to the previous owner.
+func transferOwnership(tx *sql.Tx, groupID, oldOwnerID, newOwnerID int) error {
+	if _, err := tx.Exec(queries.UpdateMemberRoleQuery, RoleOwner, newOwnerID, groupID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(queries.UpdateGroupCreatorQuery, newOwnerID, groupID)
+	return err
+}
+
+// LeaveGroup removes the current user from a group. An owner hands the group to
+// the next admin (or moderator, then longest-standing member) before leaving.
+func LeaveGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req memberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	role, err := GetMemberRole(userID, req.GroupID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if role == "" {
+		http.Error(w, "You are not a member of this group", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var newOwnerID int
+	if role == RoleOwner {
+		err = tx.QueryRow(queries.GetGroupSuccessorQuery, req.GroupID, userID).Scan(&newOwnerID)
+		if err == sql.ErrNoRows {
+			http.Error(w, "You are the only member; the owner cannot leave", http.StatusConflict)
+			return
+		}
+		if err == nil {
+			err = transferOwnership(tx, req.GroupID, userID, newOwnerID)
+		}
+		if err != nil {
+			http.Error(w, "Failed to hand over the group", http.StatusInternalServerError)
+			fmt.Println("Error transferring ownership in LeaveGroup:", err)
+			return
+		}
+	}
+
+	if _, err := tx.Exec(queries.DeleteGroupMemberQuery, userID, req.GroupID); err != nil {
+		http.Error(w, "Failed to leave group", http.StatusInternalServerError)
+		fmt.Println("Error executing DeleteGroupMemberQuery in LeaveGroup:", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Could not save changes", http.StatusInternalServerError)
+		return
+	}
+	leaveChatRoom(req.GroupID, userID)
+
+	response := map[string]interface{}{"message": "You left the group"}
+	if newOwnerID != 0 {
+		response["new_owner_id"] = newOwnerID
+	}
+	utils.SendJSONResponse(w, http.StatusOK, response)
+}
+
+// RemoveMember removes another member. Admins can remove anyone below them.
+func RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req memberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	actorRole, ok := requirePermission(w, userID, req.GroupID, PermRemoveMembers)
+	if !ok {
+		return
+	}
+
+	targetRole, err := GetMemberRole(req.UserID, req.GroupID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if targetRole == "" {
+		http.Error(w, "User is not a member of this group", http.StatusNotFound)
+		return
+	}
+	if !outranks(actorRole, targetRole) {
+		http.Error(w, "You cannot remove that member", http.StatusForbidden)
+		return
+	}
+
+	if _, err := database.DB.Exec(queries.DeleteGroupMemberQuery, req.UserID, req.GroupID); err != nil {
+		http.Error(w, "Failed to remove member", http.StatusInternalServerError)
+		fmt.Println("Error executing DeleteGroupMemberQuery in RemoveMember:", err)
+		return
+	}
+	leaveChatRoom(req.GroupID, req.UserID)
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "Member removed"})
+}
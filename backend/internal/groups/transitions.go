@@ -0,0 +1,95 @@
+package groups
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/queries"
+)
+
+// States of a group invitation or join request. Everything starts pending
+// and moves to exactly one of the other states.
+const (
+	StatusPending   = "pending"
+	StatusAccepted  = "accepted"
+	StatusDeclined  = "declined"
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired"
+)
+
+var errNotPending = errors.New("no longer pending")
+
+// responseStatus maps the action sent by the client to the state it leads to
+func responseStatus(action string) (string, bool) {
+	switch action {
+	case "accept":
+		return StatusAccepted, true
+	case "decline":
+		return StatusDeclined, true
+	}
+	return "", false
+}
+
+// resolve moves a pending invitation or join request (depending on query) to status
+func resolve(tx *sql.Tx, query string, id int, status string) error {
+	result, err := tx.Exec(query, status, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return errNotPending
+	}
+	return nil
+}
+
+// addMember puts userID into groupID and accepts anything else still pending
+// between them, so an invitation and a join request never outlive each other
+func addMember(tx *sql.Tx, userID, groupID int) error {
+	if _, err := tx.Exec(queries.InsertGroupMemberIfMissingQuery, userID, groupID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(queries.AcceptPendingInvitationsQuery, groupID, userID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(queries.AcceptPendingJoinRequestsQuery, groupID, userID)
+	return err
+}
+
+// expireStale marks invitations and join requests left pending for too long as expired
+func expireStale(tx *sql.Tx) error {
+	if _, err := tx.Exec(queries.ExpireInvitationsQuery); err != nil {
+		return err
+	}
+	_, err := tx.Exec(queries.ExpireJoinRequestsQuery)
+	return err
+}
+
+// ExpireStaleInvitations expires old pending invitations and join requests
+func ExpireStaleInvitations() {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		fmt.Println("Error starting transaction in ExpireStaleInvitations:", err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := expireStale(tx); err != nil {
+		fmt.Println("Error expiring invitations:", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fmt.Println("Error committing expired invitations:", err)
+	}
+}
+
+// writeTransitionError replies with the status code matching a failed transition
+func writeTransitionError(w http.ResponseWriter, err error, what string) {
+	if errors.Is(err, errNotPending) {
+		http.Error(w, "This "+what+" is no longer pending", http.StatusConflict)
+		return
+	}
+	http.Error(w, "Failed to update "+what, http.StatusInternalServerError)
+	fmt.Println("Error updating", what+":", err)
+}
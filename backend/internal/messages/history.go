@@ -0,0 +1,142 @@
+package messages
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/models"
+	"social-network/internal/queries"
+	"social-network/internal/sessions"
+	"social-network/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HandleGetConversations lists the current user's conversations with the latest message of each
+func HandleGetConversations(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rows, err := database.DB.Query(queries.GetConversationsQuery, userID, userID, userID, userID)
+	if err != nil {
+		fmt.Println("Error getting conversations:", err)
+		http.Error(w, "Could not retrieve conversations", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	conversations := []models.Conversation{}
+	for rows.Next() {
+		var conv models.Conversation
+		var nickname, profilePic sql.NullString
+		var createdAt time.Time
+
+		err := rows.Scan(
+			&conv.Partner.ID,
+			&nickname,
+			&conv.Partner.FirstName,
+			&conv.Partner.LastName,
+			&profilePic,
+			&conv.LastMessage.ID,
+			&conv.LastMessage.SenderID,
+			&conv.LastMessage.ReceiverID,
+			&conv.LastMessage.Content,
+			&createdAt,
+			&conv.UnreadCount,
+		)
+		if err != nil {
+			fmt.Println("Error scanning conversation:", err)
+			continue
+		}
+
+		conv.Partner.Nickname = nickname.String
+		if profilePic.Valid && profilePic.String != "" {
+			conv.Partner.ProfilePic = strings.Replace(profilePic.String, "./uploads/", "/uploads/", 1)
+		}
+		conv.LastMessage.Type = "private_message"
+		conv.LastMessage.Timestamp = createdAt.UTC().Format(time.RFC3339)
+
+		conversations = append(conversations, conv)
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, conversations)
+}
+
+// HandleGetHistory returns messages exchanged with another user, newest page first.
+// Pass the returned nextCursor as "before" to load older messages.
+func HandleGetHistory(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	partnerID, err := strconv.Atoi(r.URL.Query().Get("userId"))
+	if err != nil || partnerID <= 0 {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := strconv.Atoi(r.URL.Query().Get("before"))
+	if before < 0 {
+		before = 0
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	// Fetch one extra row to know whether an older page exists
+	rows, err := database.DB.Query(queries.GetMessageHistoryQuery,
+		userID, partnerID, partnerID, userID, before, before, limit+1,
+	)
+	if err != nil {
+		fmt.Println("Error getting message history:", err)
+		http.Error(w, "Could not retrieve messages", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	history := []models.Message{}
+	for rows.Next() {
+		var msg models.Message
+		var createdAt time.Time
+
+		err := rows.Scan(&msg.ID, &msg.SenderID, &msg.ReceiverID, &msg.Content, &createdAt)
+		if err != nil {
+			fmt.Println("Error scanning message:", err)
+			continue
+		}
+
+		msg.Type = "private_message"
+		msg.Timestamp = createdAt.UTC().Format(time.RFC3339)
+		history = append(history, msg)
+	}
+
+	hasMore := len(history) > limit
+	if hasMore {
+		history = history[:limit]
+	}
+
+	nextCursor := 0
+	if hasMore {
+		nextCursor = history[len(history)-1].ID
+	}
+
+	// Oldest first so the client can render top to bottom
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"messages":   history,
+		"nextCursor": nextCursor,
+		"hasMore":    hasMore,
+	})
+}
@@ -4,11 +4,73 @@ import (
 	"social-network/internal/database"
 	"social-network/internal/models"
 	"social-network/internal/queries"
+	"time"
 )
 
-func SaveMessage(msg models.Message) error {
-	_, err := database.DB.Exec(queries.InsertMessage,
+// SaveMessage stores a private message and fills in its ID and timestamp
+func SaveMessage(msg *models.Message) error {
+	result, err := database.DB.Exec(queries.InsertMessage,
 		msg.Content, msg.SenderID, msg.ReceiverID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	msg.ID = int(id)
+	msg.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	return nil
+}
+
+// CanMessage reports whether senderID is allowed to send a private message to receiverID
+func CanMessage(senderID, receiverID int) (bool, error) {
+	var allowed bool
+	err := database.DB.QueryRow(queries.CanMessageQuery, receiverID, senderID, senderID).Scan(&allowed)
+	return allowed, err
+}
+
+// SaveGroupMessage stores a group chat message and fills in its ID and timestamp
+func SaveGroupMessage(msg *models.GroupMessage) error {
+	result, err := database.DB.Exec(queries.InsertGroupMessageQuery,
+		msg.ChatID, msg.SenderID, msg.Content,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	msg.ID = int(id)
+	msg.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	return nil
+}
+
+// MarkConversationRead marks every message partnerID sent to readerID as read
+// and returns the ID of the newest message it marked (0 if none were unread)
+func MarkConversationRead(readerID, partnerID int) (int, error) {
+	var lastID int
+	err := database.DB.QueryRow(queries.GetLastUnreadMessageIDQuery, partnerID, readerID).Scan(&lastID)
+	if err != nil || lastID == 0 {
+		return 0, err
+	}
+
+	_, err = database.DB.Exec(queries.MarkMessagesReadQuery, partnerID, readerID, lastID)
+	if err != nil {
+		return 0, err
+	}
+	return lastID, nil
+}
+
+// GetUnreadCount returns the number of unread private messages addressed to userID
+func GetUnreadCount(userID int) (int, error) {
+	var count int
+	err := database.DB.QueryRow(queries.GetUnreadMessageCountQuery, userID).Scan(&count)
+	return count, err
 }
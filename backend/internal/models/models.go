@@ -42,9 +42,19 @@ type Post struct {
 	Likes      int       `json:"likes"`
 	IsLiked    bool      `json:"isLiked"`
 	Privacy    string    `json:"privacy"`
+	GroupID    int       `json:"groupId,omitempty"`
 	CreatedAt  time.Time `json:"createdAt"`
 }
 
+// PostEdit is an earlier version of a post, as it was before an edit
+type PostEdit struct {
+	ID       int       `json:"id"`
+	Content  string    `json:"content"`
+	Image    string    `json:"image,omitempty"`
+	Privacy  string    `json:"privacy"`
+	EditedAt time.Time `json:"editedAt"`
+}
+
 type CreatePostRequest struct {
 	Content         string `json:"content"`
 	Image           string `json:"image,omitempty"`
@@ -59,6 +69,10 @@ type Comment struct {
 	Username  string    `json:"username"`
 	Content   string    `json:"content"`
 	CreatedAt time.Time `json:"createdAt"`
+	// ParentID is the comment this one replies to, 0 for top-level comments
+	ParentID   int  `json:"parentId,omitempty"`
+	ReplyCount int  `json:"replyCount"`
+	Edited     bool `json:"edited"`
 }
 
 type Session struct {
@@ -116,6 +130,38 @@ type GroupDetails struct {
 	CreatorID   int    `json:"creator_id"`
 	Image       string `json:"image"`
 	Description string `json:"description"`
+	Role        string `json:"role,omitempty"`
+	Visibility  string `json:"visibility,omitempty"`
+}
+
+// GroupPage is a single group as seen by the viewer. Upcoming events are
+// only filled in for members.
+type GroupPage struct {
+	GroupDetails
+	MemberCount         int     `json:"member_count"`
+	IsMember            bool    `json:"is_member"`
+	PendingRequestID    int     `json:"pending_request_id,omitempty"`
+	PendingInvitationID int     `json:"pending_invitation_id,omitempty"`
+	UpcomingEvents      []Event `json:"upcoming_events"`
+}
+
+// GroupListing is a group as shown in the group directory
+type GroupListing struct {
+	GroupDetails
+	MemberCount  int    `json:"member_count"`
+	LastActivity string `json:"last_activity"`
+	IsMember     bool   `json:"is_member"`
+}
+
+// GroupMember is a member as shown in a group's member list
+type GroupMember struct {
+	ID         int       `json:"id"`
+	Nickname   string    `json:"nickname"`
+	FirstName  string    `json:"firstName"`
+	LastName   string    `json:"lastName"`
+	ProfilePic string    `json:"profilePic"`
+	Role       string    `json:"role,omitempty"`
+	JoinedAt   time.Time `json:"joinedAt"`
 }
 
 type Invitation struct {
@@ -161,16 +207,43 @@ type WSMessage struct {
 }
 
 type Message struct {
+	ID         int    `json:"id"`
 	Type       string `json:"type"`
 	SenderID   int    `json:"sender_id"`
-	ReceiverID  int    `json:"receiver_id"`
+	ReceiverID int    `json:"receiver_id"`
 	Content    string `json:"content"`
 	Timestamp  string `json:"timestamp"`
 }
 
+type Conversation struct {
+	Partner     FollowUser `json:"partner"`
+	LastMessage Message    `json:"last_message"`
+	UnreadCount int        `json:"unread_count"`
+}
+
+type ReadReceipt struct {
+	ReaderID   int `json:"reader_id"`
+	PartnerID  int `json:"partner_id"`
+	LastReadID int `json:"last_read_id"`
+}
+
+type Presence struct {
+	UserID     int    `json:"user_id"`
+	Online     bool   `json:"online"`
+	LastSeenAt string `json:"last_seen_at,omitempty"`
+}
+
+type TypingEvent struct {
+	SenderID   int  `json:"sender_id"`
+	ReceiverID int  `json:"receiver_id"`
+	IsTyping   bool `json:"is_typing"`
+}
+
 type GroupMessage struct {
+	ID         int    `json:"id"`
 	Type       string `json:"type"`
 	SenderID   int    `json:"sender_id"`
+	SenderName string `json:"sender_name"`
 	ChatID     int    `json:"chat_id"`
 	Content    string `json:"content"`
 	Timestamp  string `json:"timestamp"`
@@ -184,6 +257,28 @@ type Event struct {
 	Description string `json:"description"`
 	Age         string `json:"age"`
 	EventTime   string `json:"event_time"`
+	Status      string `json:"status,omitempty"`
+
+	// Recurring events are listed once per occurrence; EventTime is then
+	// the start of that occurrence. Single events only have occurrence 0.
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+	Occurrence int         `json:"occurrence"`
+
+	// The listing user's answer to this occurrence, empty if they have not answered
+	UserResponse string `json:"user_response,omitempty"`
+}
+
+// Recurrence repeats an event daily, weekly or monthly until a date or for Count occurrences
+type Recurrence struct {
+	Frequency string `json:"frequency"`
+	Until     string `json:"until,omitempty"`
+	Count     int    `json:"count,omitempty"`
+}
+
+// EventAttendee is a member's answer to an event
+type EventAttendee struct {
+	FollowUser
+	Response string `json:"response"`
 }
 
 type EventResponseInput struct {
@@ -191,4 +286,26 @@ type EventResponseInput struct {
 	UserID   int    `json:"user_id"`
 	GroupID  int    `json:"group_id"`
 	Response string `json:"response"`
-}
\ No newline at end of file
+	// Occurrence of a recurring event being answered; 0 for single events
+	Occurrence int `json:"occurrence"`
+}
+
+// EventCancelInput cancels a whole event, or only one occurrence when Occurrence is set
+type EventCancelInput struct {
+	EventID    int  `json:"event_id"`
+	Occurrence *int `json:"occurrence"`
+}
+
+type Notification struct {
+	ID          int    `json:"id"`
+	UserID      int    `json:"user_id"`
+	ActorID     int    `json:"actor_id"`
+	ActorName   string `json:"actor_name"`
+	ActorImage  string `json:"actor_image"`
+	Type        string `json:"type"`
+	GroupID     int    `json:"group_id,omitempty"`
+	ReferenceID int    `json:"reference_id,omitempty"`
+	Message     string `json:"message"`
+	IsRead      bool   `json:"is_read"`
+	CreatedAt   string `json:"created_at"`
+}
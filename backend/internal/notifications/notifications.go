@@ -0,0 +1,301 @@
+package notifications
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/models"
+	"social-network/internal/queries"
+	"social-network/internal/sessions"
+	"social-network/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notification types
+const (
+	TypeFollowRequest    = "follow_request"
+	TypeNewFollower      = "new_follower"
+	TypeGroupInvitation  = "group_invitation"
+	TypeGroupJoinRequest = "group_join_request"
+	TypeGroupEvent       = "group_event"
+	TypeEventUpdated     = "group_event_updated"
+	TypeEventCancelled   = "group_event_cancelled"
+	TypeEventReminder    = "group_event_reminder"
+)
+
+// Longest event title quoted in a reminder, keeping messages under the 500 character limit
+const maxReminderTitle = 200
+
+// EventNotification is the WebSocket message type used for live notifications
+const EventNotification = "notification"
+
+// Pusher delivers a payload to every open connection of a user
+type Pusher interface {
+	SendToUser(userID int, msg []byte)
+}
+
+var pusher Pusher
+
+// SetPusher registers the live delivery channel, normally the WebSocket manager
+func SetPusher(p Pusher) {
+	pusher = p
+}
+
+// Notify stores a notification for userID and pushes it to them if they are connected.
+// groupID and referenceID are optional and may be 0.
+func Notify(userID, actorID int, notificationType string, groupID, referenceID int) error {
+	if userID == actorID {
+		return nil
+	}
+
+	return send(models.Notification{
+		UserID:      userID,
+		ActorID:     actorID,
+		Type:        notificationType,
+		GroupID:     groupID,
+		ReferenceID: referenceID,
+	}, func(actorName, groupTitle string) string {
+		return describe(notificationType, actorName, groupTitle)
+	})
+}
+
+// NotifyEventReminder reminds userID that an event they are going to starts in startsIn.
+// The event's creator is the actor; unlike Notify they are reminded of their own events too.
+func NotifyEventReminder(userID, creatorID, groupID, eventID int, title string, startsIn time.Duration) error {
+	if runes := []rune(title); len(runes) > maxReminderTitle {
+		title = string(runes[:maxReminderTitle]) + "…"
+	}
+	return send(models.Notification{
+		UserID:      userID,
+		ActorID:     creatorID,
+		Type:        TypeEventReminder,
+		GroupID:     groupID,
+		ReferenceID: eventID,
+	}, func(actorName, groupTitle string) string {
+		return "Reminder: " + title + " in " + groupTitle + " starts in " + describeDelay(startsIn)
+	})
+}
+
+// send fills in the actor, builds the message, then stores and pushes n
+func send(n models.Notification, message func(actorName, groupTitle string) string) error {
+
+	var actorImage sql.NullString
+	err := database.DB.QueryRow(queries.GetNotificationActorQuery, n.ActorID).Scan(&n.ActorName, &actorImage)
+	if err != nil {
+		return fmt.Errorf("loading actor: %w", err)
+	}
+	if actorImage.Valid && actorImage.String != "" {
+		n.ActorImage = strings.Replace(actorImage.String, "./uploads/", "/uploads/", 1)
+	}
+
+	var groupTitle string
+	if n.GroupID > 0 {
+		if err := database.DB.QueryRow(queries.GetGroupTitleQuery, n.GroupID).Scan(&groupTitle); err != nil {
+			return fmt.Errorf("loading group: %w", err)
+		}
+	}
+	n.Message = message(n.ActorName, groupTitle)
+
+	result, err := database.DB.Exec(queries.InsertNotificationQuery,
+		n.UserID, n.ActorID, n.Type, n.GroupID, n.ReferenceID, n.Message,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	n.ID = int(id)
+	n.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	push(n)
+	return nil
+}
+
+func push(n models.Notification) {
+	if pusher == nil {
+		return
+	}
+	out, err := json.Marshal(models.WSMessage{Type: EventNotification, Data: n})
+	if err != nil {
+		return
+	}
+	pusher.SendToUser(n.UserID, out)
+}
+
+func describe(notificationType, actorName, groupTitle string) string {
+	switch notificationType {
+	case TypeFollowRequest:
+		return actorName + " wants to follow you"
+	case TypeNewFollower:
+		return actorName + " started following you"
+	case TypeGroupInvitation:
+		return actorName + " invited you to join " + groupTitle
+	case TypeGroupJoinRequest:
+		return actorName + " asked to join " + groupTitle
+	case TypeGroupEvent:
+		return actorName + " created a new event in " + groupTitle
+	case TypeEventUpdated:
+		return actorName + " changed an event in " + groupTitle
+	case TypeEventCancelled:
+		return actorName + " cancelled an event in " + groupTitle
+	default:
+		return actorName + " sent you a notification"
+	}
+}
+
+// describeDelay words how far away an event is, e.g. "2 days" or "45 minutes"
+func describeDelay(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return strconv.Itoa(n) + " " + unit + "s"
+	}
+	d = d.Round(time.Minute)
+	switch {
+	case d >= 48*time.Hour:
+		return plural(int(d.Round(24*time.Hour)/(24*time.Hour)), "day")
+	case d >= time.Hour:
+		return plural(int(d.Round(time.Hour)/time.Hour), "hour")
+	default:
+		return plural(max(1, int(d/time.Minute)), "minute")
+	}
+}
+
+// HandleGetNotifications lists the current user's notifications, newest first.
+// Pass the returned nextCursor as "before" to load older entries.
+func HandleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	before, _ := strconv.Atoi(r.URL.Query().Get("before"))
+	if before < 0 {
+		before = 0
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	rows, err := database.DB.Query(queries.GetNotificationsQuery, userID, before, before, limit+1)
+	if err != nil {
+		fmt.Println("Error getting notifications:", err)
+		http.Error(w, "Could not retrieve notifications", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		var actorImage sql.NullString
+		var createdAt time.Time
+
+		err := rows.Scan(
+			&n.ID, &n.UserID, &n.ActorID, &n.ActorName, &actorImage, &n.Type,
+			&n.GroupID, &n.ReferenceID, &n.Message, &n.IsRead, &createdAt,
+		)
+		if err != nil {
+			fmt.Println("Error scanning notification:", err)
+			continue
+		}
+
+		if actorImage.Valid && actorImage.String != "" {
+			n.ActorImage = strings.Replace(actorImage.String, "./uploads/", "/uploads/", 1)
+		}
+		n.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+		list = append(list, n)
+	}
+
+	hasMore := len(list) > limit
+	nextCursor := 0
+	if hasMore {
+		list = list[:limit]
+		nextCursor = list[len(list)-1].ID
+	}
+
+	var unread int
+	if err := database.DB.QueryRow(queries.GetUnreadNotificationCountQuery, userID).Scan(&unread); err != nil {
+		fmt.Println("Error counting unread notifications:", err)
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"notifications": list,
+		"unreadCount":   unread,
+		"nextCursor":    nextCursor,
+		"hasMore":       hasMore,
+	})
+}
+
+// HandleMarkRead marks a single notification as read
+func HandleMarkRead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	result, err := database.DB.Exec(queries.MarkNotificationReadQuery, req.ID, userID)
+	if err != nil {
+		http.Error(w, "Could not update notification", http.StatusInternalServerError)
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		http.Error(w, "Notification not found", http.StatusNotFound)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "Notification marked as read"})
+}
+
+// HandleMarkAllRead marks every notification of the current user as read
+func HandleMarkAllRead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	result, err := database.DB.Exec(queries.MarkAllNotificationsReadQuery, userID)
+	if err != nil {
+		http.Error(w, "Could not update notifications", http.StatusInternalServerError)
+		return
+	}
+
+	updated, _ := result.RowsAffected()
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "All notifications marked as read",
+		"updated": updated,
+	})
+}
@@ -0,0 +1,139 @@
+package posts
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/queries"
+	"social-network/internal/sessions"
+	"social-network/internal/utils"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Longest comment the post_comments table accepts
+const maxCommentLength = 500
+
+// commentOwners is who a comment belongs to
+type commentOwners struct {
+	AuthorID     int
+	PostID       int
+	PostAuthorID int
+}
+
+// validCommentContent replies 400 unless content is a non-empty comment of allowed length
+func validCommentContent(w http.ResponseWriter, content string) bool {
+	if content == "" {
+		http.Error(w, "Content is required", http.StatusBadRequest)
+		return false
+	}
+	if utf8.RuneCountInString(content) > maxCommentLength {
+		http.Error(w, "Content must be at most 500 characters", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// loadComment replies 404 if commentID does not exist
+func loadComment(w http.ResponseWriter, commentID int) (commentOwners, bool) {
+	var c commentOwners
+	err := database.DB.QueryRow(queries.GetCommentForEditQuery, commentID).Scan(&c.AuthorID, &c.PostID, &c.PostAuthorID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return c, false
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		fmt.Println("Error executing GetCommentForEditQuery:", err)
+		return c, false
+	}
+	return c, true
+}
+
+// HandleUpdateComment changes the text of one of the user's own comments
+func HandleUpdateComment(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	commentID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	req.Content = strings.TrimSpace(req.Content)
+	if !validCommentContent(w, req.Content) {
+		return
+	}
+
+	comment, ok := loadComment(w, commentID)
+	if !ok {
+		return
+	}
+	if !requirePostVisible(w, comment.PostID, userID) {
+		return
+	}
+	// Post authors may remove comments on their posts, but not put words in someone's mouth
+	if comment.AuthorID != userID {
+		http.Error(w, "Only the author can edit this comment", http.StatusForbidden)
+		return
+	}
+
+	if _, err := database.DB.Exec(queries.UpdateCommentQuery, req.Content, commentID); err != nil {
+		fmt.Println("Error updating comment:", err)
+		http.Error(w, "Could not update comment", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"id":      commentID,
+		"content": req.Content,
+		"message": "Comment updated successfully",
+	})
+}
+
+// HandleDeleteComment deletes a comment and every reply below it. The
+// comment's author and the author of the post can delete it.
+func HandleDeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	commentID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	comment, ok := loadComment(w, commentID)
+	if !ok {
+		return
+	}
+	if comment.AuthorID != userID && comment.PostAuthorID != userID {
+		http.Error(w, "You cannot delete this comment", http.StatusForbidden)
+		return
+	}
+
+	if _, err := database.DB.Exec(queries.DeleteCommentThreadQuery, commentID); err != nil {
+		fmt.Println("Error deleting comment:", err)
+		http.Error(w, "Could not delete comment", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "Comment deleted"})
+}
@@ -0,0 +1,258 @@
+package posts
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/models"
+	"social-network/internal/queries"
+	"social-network/internal/sessions"
+	"social-network/internal/utils"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Longest post content the posts table accepts
+const maxPostLength = 1000
+
+func validPrivacy(privacy string) bool {
+	return privacy == "public" || privacy == "followers" || privacy == "private"
+}
+
+// postForEdit is the editable part of a post
+type postForEdit struct {
+	AuthorID int
+	Content  string
+	Image    string
+	Privacy  string
+	GroupID  int
+}
+
+// loadPostForEdit replies with an error unless postID exists and userID wrote it
+func loadPostForEdit(w http.ResponseWriter, postID, userID int) (postForEdit, bool) {
+	var p postForEdit
+	err := database.DB.QueryRow(queries.GetPostForEditQuery, postID).Scan(&p.AuthorID, &p.Content, &p.Image, &p.Privacy, &p.GroupID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return p, false
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		fmt.Println("Error executing GetPostForEditQuery:", err)
+		return p, false
+	}
+	if p.AuthorID != userID {
+		http.Error(w, "Only the author can change this post", http.StatusForbidden)
+		return p, false
+	}
+	return p, true
+}
+
+// HandleUpdatePost edits a post. It takes the same form fields as
+// HandleCreatePost; fields left out keep their value, remove_image=true
+// drops the image. For private posts, selectedViewers[] replaces the viewers.
+// A replaced image stays on disk for the edit history until the post is deleted.
+func HandleUpdatePost(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	postID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	err = utils.ParseUploadForm(w, r)
+	if err != nil {
+		http.Error(w, "Could not parse form", http.StatusBadRequest)
+		return
+	}
+
+	old, ok := loadPostForEdit(w, postID, userID)
+	if !ok {
+		return
+	}
+	post := old
+
+	form := r.MultipartForm.Value
+	if _, ok := form["content"]; ok {
+		post.Content = strings.TrimSpace(r.FormValue("content"))
+		if post.Content == "" {
+			http.Error(w, "Content is required", http.StatusBadRequest)
+			return
+		}
+		if utf8.RuneCountInString(post.Content) > maxPostLength {
+			http.Error(w, "Content must be at most 1000 characters", http.StatusBadRequest)
+			return
+		}
+	}
+	if _, ok := form["privacy"]; ok {
+		// Group posts are visible to the group's members, not by privacy setting
+		if post.GroupID != 0 {
+			http.Error(w, "Group posts have no privacy setting", http.StatusBadRequest)
+			return
+		}
+		post.Privacy = r.FormValue("privacy")
+		if !validPrivacy(post.Privacy) {
+			http.Error(w, "Privacy must be public, followers or private", http.StatusBadRequest)
+			return
+		}
+	}
+	_, viewersSent := form["selectedViewers[]"]
+	replaceViewers := post.Privacy != old.Privacy || (post.Privacy == "private" && viewersSent)
+
+	if r.FormValue("remove_image") == "true" {
+		post.Image = ""
+	}
+	file, header, err := r.FormFile("image")
+	if err == nil {
+		defer file.Close()
+
+		filename, err := utils.SaveUpload(file, header)
+		if err != nil {
+			fmt.Println("Error saving file:", err)
+			http.Error(w, "Could not save image", http.StatusInternalServerError)
+			return
+		}
+		post.Image = "./uploads/" + filename
+	}
+
+	if post == old && !replaceViewers {
+		updated, err := GetPostByID(postID, userID)
+		if err != nil {
+			http.Error(w, "Could not retrieve post", http.StatusInternalServerError)
+			return
+		}
+		utils.SendJSONResponse(w, http.StatusOK, updated)
+		return
+	}
+
+	if err := savePostEdit(postID, old, post, replaceViewers, r.Form["selectedViewers[]"]); err != nil {
+		fmt.Println("Error updating post:", err)
+		http.Error(w, "Could not update post", http.StatusInternalServerError)
+		if post.Image != old.Image {
+			utils.RemoveUpload(post.Image)
+		}
+		return
+	}
+
+	updated, err := GetPostByID(postID, userID)
+	if err != nil {
+		http.Error(w, "Could not retrieve post", http.StatusInternalServerError)
+		return
+	}
+	utils.SendJSONResponse(w, http.StatusOK, updated)
+}
+
+// savePostEdit stores the old version of a post in its history and applies the new one
+func savePostEdit(postID int, old, post postForEdit, replaceViewers bool, viewerIDs []string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Changing only who can see a private post leaves no new version behind
+	if post != old {
+		if _, err := tx.Exec(queries.InsertPostEditQuery, postID, old.Content, old.Image, old.Privacy); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(queries.UpdatePostQuery, post.Content, post.Image, post.Privacy, postID); err != nil {
+			return err
+		}
+	}
+
+	if replaceViewers {
+		if _, err := tx.Exec(queries.DeletePostViewersQuery, postID); err != nil {
+			return err
+		}
+		if post.Privacy == "private" {
+			for _, viewerIDStr := range viewerIDs {
+				viewerID, err := strconv.Atoi(viewerIDStr)
+				if err != nil {
+					continue
+				}
+				if _, err := tx.Exec(queries.InsertPostViewerQuery, postID, viewerID); err != nil && !database.IsUniqueViolation(err) {
+					return err
+				}
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// HandleDeletePost deletes one of the user's own posts
+func HandleDeletePost(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	postID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	post, ok := loadPostForEdit(w, postID, userID)
+	if !ok {
+		return
+	}
+
+	if err := deletePost(postID, post.Image); err != nil {
+		fmt.Println("Error deleting post:", err)
+		http.Error(w, "Could not delete post", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "Post deleted"})
+}
+
+// HandleGetPostHistory lists the earlier versions of a post, newest first.
+// Anyone who can see the post can read them, including content the author
+// has since edited out
+func HandleGetPostHistory(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	postID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	if !requirePostVisible(w, postID, userID) {
+		return
+	}
+
+	rows, err := database.DB.Query(queries.GetPostEditsQuery, postID)
+	if err != nil {
+		http.Error(w, "Could not retrieve post history", http.StatusInternalServerError)
+		fmt.Println("Error getting post history:", err)
+		return
+	}
+	defer rows.Close()
+
+	edits := []models.PostEdit{}
+	for rows.Next() {
+		var edit models.PostEdit
+		err := rows.Scan(&edit.ID, &edit.Content, &edit.Image, &edit.Privacy, &edit.EditedAt)
+		if err != nil {
+			fmt.Println("Error scanning post edit:", err)
+			continue
+		}
+		edit.Image = strings.Replace(edit.Image, "./uploads/", "/uploads/", 1)
+		edits = append(edits, edit)
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, edits)
+}
@@ -0,0 +1,273 @@
+package posts
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/groups"
+	"social-network/internal/models"
+	"social-network/internal/queries"
+	"social-network/internal/sessions"
+	"social-network/internal/utils"
+	"strconv"
+	"strings"
+)
+
+// HandleCreateGroupPost creates a post inside a group the user belongs to
+func HandleCreateGroupPost(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = utils.ParseUploadForm(w, r)
+	if err != nil {
+		http.Error(w, "Could not parse form", http.StatusBadRequest)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.FormValue("group_id"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+	if !requireGroupMember(w, userID, groupID) {
+		return
+	}
+
+	content := strings.TrimSpace(r.FormValue("content"))
+	if content == "" {
+		http.Error(w, "Content is required", http.StatusBadRequest)
+		return
+	}
+
+	var imagePath string
+
+	file, header, err := r.FormFile("image")
+	if err == nil {
+		defer file.Close()
+
+		filename, err := utils.SaveUpload(file, header)
+		if err != nil {
+			fmt.Println("Error saving file:", err)
+			http.Error(w, "Could not save image", http.StatusInternalServerError)
+			return
+		}
+		imagePath = "./uploads/" + filename
+	}
+
+	result, err := database.DB.Exec(queries.InsertGroupPostQuery, userID, content, imagePath, groupID)
+	if err != nil {
+		fmt.Println("Error inserting group post:", err)
+		http.Error(w, "Could not create post", http.StatusInternalServerError)
+		return
+	}
+
+	postID, err := result.LastInsertId()
+	if err != nil {
+		http.Error(w, "Could not get post ID", http.StatusInternalServerError)
+		return
+	}
+
+	post, err := GetPostByID(int(postID), userID)
+	if err != nil {
+		http.Error(w, "Could not retrieve post", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusCreated, post)
+}
+
+// HandleGetGroupPosts returns a page of a group's posts, newest first.
+// Pass the returned nextCursor as "before" to load older posts.
+func HandleGetGroupPosts(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.URL.Query().Get("group_id"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+	if !requireGroupMember(w, userID, groupID) {
+		return
+	}
+
+	before, _ := strconv.Atoi(r.URL.Query().Get("before"))
+	if before < 0 {
+		before = 0
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	rows, err := database.DB.Query(queries.GetGroupPostsQuery, userID, groupID, before, before, limit+1)
+	if err != nil {
+		fmt.Println("Error getting group posts:", err)
+		http.Error(w, "Could not retrieve posts", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	for rows.Next() {
+		var post models.Post
+		var profilePic sql.NullString
+		var image sql.NullString
+
+		err := rows.Scan(
+			&post.ID,
+			&post.UserID,
+			&post.Content,
+			&image,
+			&post.CreatedAt,
+			&post.Username,
+			&profilePic,
+			&post.Comments,
+			&post.Likes,
+			&post.IsLiked,
+			&post.Privacy,
+		)
+		if err != nil {
+			fmt.Println("Error scanning group post:", err)
+			continue
+		}
+
+		if profilePic.Valid && profilePic.String != "" {
+			post.ProfilePic = strings.Replace(profilePic.String, "./uploads/", "/uploads/", 1)
+		}
+		if image.Valid && image.String != "" {
+			post.Image = strings.Replace(image.String, "./uploads/", "/uploads/", 1)
+		}
+		post.GroupID = groupID
+		post.Time = formatTimeAgo(post.CreatedAt)
+
+		posts = append(posts, post)
+	}
+
+	hasMore := len(posts) > limit
+	if hasMore {
+		posts = posts[:limit]
+	}
+
+	nextCursor := 0
+	if hasMore {
+		nextCursor = posts[len(posts)-1].ID
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"posts":      posts,
+		"nextCursor": nextCursor,
+		"hasMore":    hasMore,
+	})
+}
+
+// HandleDeleteGroupPost removes a group post. Authors can delete their own
+// posts; moderators and above can delete anyone's.
+func HandleDeleteGroupPost(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	postID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	var authorID, groupID int
+	var imagePath string
+	err = database.DB.QueryRow(queries.GetPostGroupQuery, postID).Scan(&authorID, &groupID, &imagePath)
+	if err != nil || groupID == 0 {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	if authorID != userID {
+		role, err := groups.GetMemberRole(userID, groupID)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if !groups.HasPermission(role, groups.PermDeletePosts) {
+			http.Error(w, "You cannot delete this post", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := deletePost(postID, imagePath); err != nil {
+		fmt.Println("Error deleting group post:", err)
+		http.Error(w, "Could not delete post", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "Post deleted"})
+}
+
+// deletePost removes a post along with its comments, likes, viewers and edit
+// history, then the files of its current image and of any earlier versions
+func deletePost(postID int, image string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	images := []string{image}
+	rows, err := tx.Query(queries.GetPostEditImagesQuery, postID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err == nil {
+			images = append(images, path)
+		}
+	}
+	rows.Close()
+
+	for _, query := range []string{
+		queries.DeletePostEditsQuery,
+		queries.DeletePostCommentsQuery,
+		queries.DeletePostLikesQuery,
+		queries.DeletePostViewersQuery,
+		queries.DeletePostQuery,
+	} {
+		if _, err := tx.Exec(query, postID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, path := range images {
+		if err := utils.RemoveUpload(path); err != nil {
+			fmt.Println("Error removing post image:", err)
+		}
+	}
+	return nil
+}
+
+// requireGroupMember replies 403 unless userID belongs to groupID
+func requireGroupMember(w http.ResponseWriter, userID, groupID int) bool {
+	isMember, err := groups.IsGroupMember(userID, groupID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+	if !isMember {
+		http.Error(w, "You are not a member of this group", http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -4,10 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"social-network/internal/database"
 	"social-network/internal/models"
 	"social-network/internal/queries"
@@ -16,8 +13,6 @@ import (
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/gofrs/uuid"
 )
 
 // HandleCreatePost creates a new post
@@ -30,7 +25,7 @@ func HandleCreatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse multipart form for potential image upload
-	err = r.ParseMultipartForm(10 << 20) // 10MB max
+	err = utils.ParseUploadForm(w, r)
 	if err != nil {
 		http.Error(w, "Could not parse form", http.StatusBadRequest)
 		return
@@ -54,26 +49,13 @@ func HandleCreatePost(w http.ResponseWriter, r *http.Request) {
 	if err == nil {
 		defer file.Close()
 
-		// Create unique filename
-		ext := filepath.Ext(header.Filename)
-		filename := uuid.Must(uuid.NewV4()).String() + ext
-		imagePath = "./uploads/" + filename
-
-		// Save file
-		dst, err := os.Create(imagePath)
-		if err != nil {
-			fmt.Println("Error creating file:", err)
-			http.Error(w, "Could not save image", http.StatusInternalServerError)
-			return
-		}
-		defer dst.Close()
-
-		_, err = io.Copy(dst, file)
+		filename, err := utils.SaveUpload(file, header)
 		if err != nil {
 			fmt.Println("Error saving file:", err)
 			http.Error(w, "Could not save image", http.StatusInternalServerError)
 			return
 		}
+		imagePath = "./uploads/" + filename
 	}
 
 	// Insert post into database
@@ -115,6 +97,34 @@ func HandleCreatePost(w http.ResponseWriter, r *http.Request) {
 	utils.SendJSONResponse(w, http.StatusCreated, post)
 }
 
+// HandleGetPost returns a single post, or 404 if the user may not see it
+func HandleGetPost(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	postID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	if !requirePostVisible(w, postID, userID) {
+		return
+	}
+
+	post, err := GetPostByID(postID, userID)
+	if err != nil {
+		http.Error(w, "Could not retrieve post", http.StatusInternalServerError)
+		fmt.Println("Error getting post:", err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, post)
+}
+
 func HandleGetPosts(w http.ResponseWriter, r *http.Request) {
 
 	userID, _, err := sessions.GetUserFromSession(r)
@@ -201,6 +211,10 @@ func HandleLikePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !requirePostVisible(w, postID, userID) {
+		return
+	}
+
 	if r.Method == http.MethodPost {
 		_, err = database.DB.Exec(queries.InsertLikeQuery, postID, userID)
 		if err != nil {
@@ -222,7 +236,7 @@ func HandleLikePost(w http.ResponseWriter, r *http.Request) {
 	utils.SendJSONResponse(w, http.StatusOK, map[string]string{"message": "Success"})
 }
 
-// HandleCreateComment adds a comment to a post
+// HandleCreateComment adds a comment to a post, or a reply when parentId is set
 func HandleCreateComment(w http.ResponseWriter, r *http.Request) {
 	userID, _, err := sessions.GetUserFromSession(r)
 	if err != nil {
@@ -231,8 +245,9 @@ func HandleCreateComment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		PostID  int    `json:"postId"`
-		Content string `json:"content"`
+		PostID   int    `json:"postId"`
+		ParentID int    `json:"parentId"`
+		Content  string `json:"content"`
 	}
 
 	err = json.NewDecoder(r.Body).Decode(&req)
@@ -242,13 +257,27 @@ func HandleCreateComment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	req.Content = strings.TrimSpace(req.Content)
-	if req.Content == "" {
-		http.Error(w, "Content is required", http.StatusBadRequest)
+	if !validCommentContent(w, req.Content) {
 		return
 	}
 
+	if !requirePostVisible(w, req.PostID, userID) {
+		return
+	}
+
+	if req.ParentID != 0 {
+		parent, ok := loadComment(w, req.ParentID)
+		if !ok {
+			return
+		}
+		if parent.PostID != req.PostID {
+			http.Error(w, "The parent comment belongs to another post", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Insert comment
-	result, err := database.DB.Exec(queries.InsertCommentQuery, req.PostID, userID, req.Content)
+	result, err := database.DB.Exec(queries.InsertCommentQuery, req.PostID, userID, req.Content, req.ParentID)
 	if err != nil {
 		http.Error(w, "Could not create comment", http.StatusInternalServerError)
 		return
@@ -262,7 +291,17 @@ func HandleCreateComment(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// HandleGetComments returns a page of a post's top-level comments, oldest
+// first, each with its number of replies. With parentId it returns the
+// replies to that comment instead. Pass the returned nextCursor as "after"
+// to load the next page.
 func HandleGetComments(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := sessions.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	postIDStr := r.URL.Query().Get("postId")
 	postID, err := strconv.Atoi(postIDStr)
 	if err != nil {
@@ -270,14 +309,33 @@ func HandleGetComments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rows, err := database.DB.Query(queries.GetCommentsByPostQuery, postID)
+	if !requirePostVisible(w, postID, userID) {
+		return
+	}
+
+	parentID, _ := strconv.Atoi(r.URL.Query().Get("parentId"))
+	if parentID < 0 {
+		parentID = 0
+	}
+
+	after, _ := strconv.Atoi(r.URL.Query().Get("after"))
+	if after < 0 {
+		after = 0
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	rows, err := database.DB.Query(queries.GetCommentsByPostQuery, postID, parentID, after, limit+1)
 	if err != nil {
 		http.Error(w, "Could not retrieve comments", http.StatusInternalServerError)
 		return
 	}
 	defer rows.Close()
 
-	var comments []models.Comment
+	comments := []models.Comment{}
 	for rows.Next() {
 		var comment models.Comment
 		err := rows.Scan(
@@ -287,6 +345,9 @@ func HandleGetComments(w http.ResponseWriter, r *http.Request) {
 			&comment.Content,
 			&comment.CreatedAt,
 			&comment.Username,
+			&comment.ParentID,
+			&comment.Edited,
+			&comment.ReplyCount,
 		)
 		if err != nil {
 			continue
@@ -294,7 +355,21 @@ func HandleGetComments(w http.ResponseWriter, r *http.Request) {
 		comments = append(comments, comment)
 	}
 
-	utils.SendJSONResponse(w, http.StatusOK, comments)
+	hasMore := len(comments) > limit
+	if hasMore {
+		comments = comments[:limit]
+	}
+
+	nextCursor := 0
+	if hasMore {
+		nextCursor = comments[len(comments)-1].ID
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"comments":   comments,
+		"nextCursor": nextCursor,
+		"hasMore":    hasMore,
+	})
 }
 
 // Helper function to get post by ID
@@ -315,6 +390,7 @@ func GetPostByID(postID, userID int) (*models.Post, error) {
 		&post.Likes,
 		&post.IsLiked,
 		&post.Privacy,
+		&post.GroupID,
 	)
 
 	if err != nil {
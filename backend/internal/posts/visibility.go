@@ -0,0 +1,34 @@
+package posts
+
+import (
+	"fmt"
+	"net/http"
+	"social-network/internal/database"
+	"social-network/internal/queries"
+)
+
+// canViewPost reports whether userID may see postID; missing posts are not
+// visible. Authors always see their posts, group posts are visible to the
+// group's members, and other posts follow the same privacy rules as the feed.
+func canViewPost(postID, userID int) (bool, error) {
+	var visible bool
+	err := database.DB.QueryRow(queries.CanViewPostQuery, postID, userID, userID, userID, userID).Scan(&visible)
+	return visible, err
+}
+
+// requirePostVisible replies 404 unless userID may see postID, so posts
+// hidden from the user look the same as posts that do not exist. Every
+// handler that takes a post ID from the client checks it first.
+func requirePostVisible(w http.ResponseWriter, postID, userID int) bool {
+	visible, err := canViewPost(postID, userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		fmt.Println("Error checking post visibility:", err)
+		return false
+	}
+	if !visible {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
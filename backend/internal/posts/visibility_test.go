@@ -0,0 +1,152 @@
+package posts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"social-network/internal/database"
+	"social-network/internal/queries"
+	"social-network/internal/sessions"
+	"strconv"
+	"testing"
+)
+
+// Users of the test database, in insertion order
+const (
+	author = iota + 1
+	follower
+	pendingFollower
+	chosenViewer
+	stranger
+	groupMember
+)
+
+// Posts of the test database, in insertion order
+const (
+	publicPost = iota + 1
+	followersPost
+	privatePost
+	groupPost
+)
+
+// setupVisibilityDB migrates a fresh database and fills it with one post of
+// each kind by author, plus users related to author in different ways
+func setupVisibilityDB(t *testing.T) {
+	t.Helper()
+
+	prev := database.DB
+	database.ConnectAndMigrate(filepath.Join(t.TempDir(), "test.db"), "file://../database/migrations/sqlite")
+	t.Cleanup(func() {
+		database.Close()
+		database.DB = prev
+	})
+
+	for _, name := range []string{"author", "follower", "pending", "viewer", "stranger", "member"} {
+		mustExec(t, queries.InsertUserQuery, name+"@example.com", "x", name, name, "Test", "1990-01-01", "")
+	}
+	mustExec(t, `INSERT INTO follows (follower_id, following_id, status) VALUES (?, ?, 'accepted')`, follower, author)
+	mustExec(t, `INSERT INTO follows (follower_id, following_id, status) VALUES (?, ?, 'pending')`, pendingFollower, author)
+
+	mustExec(t, queries.InsertPostQuery, author, "public", "", "public")
+	mustExec(t, queries.InsertPostQuery, author, "followers", "", "followers")
+	mustExec(t, queries.InsertPostQuery, author, "private", "", "private")
+	mustExec(t, queries.InsertPostViewerQuery, privatePost, chosenViewer)
+
+	mustExec(t, queries.InsertGroupQuery, "group", "", author, "", "private")
+	mustExec(t, queries.InsertGroupMemberQuery, author, 1)
+	mustExec(t, queries.InsertGroupMemberQuery, groupMember, 1)
+	mustExec(t, queries.InsertGroupPostQuery, author, "group", "", 1)
+}
+
+func mustExec(t *testing.T, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := database.DB.Exec(query, args...); err != nil {
+		t.Fatalf("seeding test database: %v", err)
+	}
+}
+
+func TestPostVisibility(t *testing.T) {
+	setupVisibilityDB(t)
+
+	tests := []struct {
+		name   string
+		postID int
+		userID int
+		want   bool
+	}{
+		{"public post, author", publicPost, author, true},
+		{"public post, follower", publicPost, follower, true},
+		{"public post, stranger", publicPost, stranger, true},
+
+		{"followers post, author", followersPost, author, true},
+		{"followers post, follower", followersPost, follower, true},
+		{"followers post, pending follower", followersPost, pendingFollower, false},
+		{"followers post, chosen viewer who does not follow", followersPost, chosenViewer, false},
+		{"followers post, stranger", followersPost, stranger, false},
+
+		{"private post, author", privatePost, author, true},
+		{"private post, chosen viewer", privatePost, chosenViewer, true},
+		{"private post, follower who was not chosen", privatePost, follower, false},
+		{"private post, stranger", privatePost, stranger, false},
+
+		{"group post, member", groupPost, groupMember, true},
+		{"group post, follower who is not a member", groupPost, follower, false},
+		{"group post, stranger", groupPost, stranger, false},
+
+		{"missing post", 99, author, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canViewPost(tt.postID, tt.userID)
+			if err != nil {
+				t.Fatalf("canViewPost: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("canViewPost(%d, %d) = %v, want %v", tt.postID, tt.userID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleGetPost(t *testing.T) {
+	setupVisibilityDB(t)
+
+	tests := []struct {
+		name       string
+		postID     string
+		userID     int
+		wantStatus int
+	}{
+		{"public post, stranger", strconv.Itoa(publicPost), stranger, http.StatusOK},
+		{"followers post, follower", strconv.Itoa(followersPost), follower, http.StatusOK},
+		{"followers post, stranger", strconv.Itoa(followersPost), stranger, http.StatusNotFound},
+		{"private post, chosen viewer", strconv.Itoa(privatePost), chosenViewer, http.StatusOK},
+		{"private post, follower", strconv.Itoa(privatePost), follower, http.StatusNotFound},
+		{"group post, member", strconv.Itoa(groupPost), groupMember, http.StatusOK},
+		{"group post, stranger", strconv.Itoa(groupPost), stranger, http.StatusNotFound},
+		{"missing post", "99", author, http.StatusNotFound},
+		{"invalid ID", "abc", author, http.StatusBadRequest},
+		{"not logged in", strconv.Itoa(publicPost), 0, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/posts?id="+tt.postID, nil)
+			if tt.userID != 0 {
+				sessionID, err := sessions.CreateSession(tt.userID)
+				if err != nil {
+					t.Fatalf("CreateSession: %v", err)
+				}
+				req.AddCookie(&http.Cookie{Name: sessions.SessionCookieName, Value: sessionID})
+			}
+			rec := httptest.NewRecorder()
+
+			HandleGetPost(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %q)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
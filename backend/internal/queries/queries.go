@@ -25,7 +25,7 @@ const (
 		INNER JOIN users u ON p.user_id = u.id
 		LEFT JOIN post_comments c ON p.id = c.post_id
 		LEFT JOIN post_likes l ON p.id = l.post_id
-		WHERE (
+		WHERE p.group_id IS NULL AND (
 			? = 0 OR
 			p.privacy = 'public' OR
 			p.user_id = ? OR
@@ -50,7 +50,7 @@ const (
 			COUNT(DISTINCT c.id) as comment_count,
 			COUNT(DISTINCT l.id) as like_count,
 			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = ?) as is_liked,
-			p.privacy
+			p.privacy, COALESCE(p.group_id, 0)
 		FROM posts p
 		INNER JOIN users u ON p.user_id = u.id
 		LEFT JOIN post_comments c ON p.id = c.post_id
@@ -60,20 +60,101 @@ const (
 
 	InsertPostViewerQuery = `INSERT INTO post_viewers (post_id, user_id) VALUES (?, ?)`
 
+	// Group post queries
+	InsertGroupPostQuery = `INSERT INTO posts (user_id, content, image, group_id) VALUES (?, ?, ?, ?)`
+	GetGroupPostsQuery   = `
+		SELECT 
+			p.id, p.user_id, p.content, p.image, p.created_at,
+			COALESCE(u.nickname, u.first_name || ' ' || u.last_name) as username,
+			u.image as profile_pic,
+			COUNT(DISTINCT c.id) as comment_count,
+			COUNT(DISTINCT l.id) as like_count,
+			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = ?) as is_liked,
+			p.privacy
+		FROM posts p
+		INNER JOIN users u ON p.user_id = u.id
+		LEFT JOIN post_comments c ON p.id = c.post_id
+		LEFT JOIN post_likes l ON p.id = l.post_id
+		WHERE p.group_id = ? AND (? = 0 OR p.id < ?)
+		GROUP BY p.id
+		ORDER BY p.id DESC
+		LIMIT ?`
+	GetPostGroupQuery = `SELECT user_id, COALESCE(group_id, 0), COALESCE(image, '') FROM posts WHERE id = ?`
+
+	// Post editing. The previous version is copied to post_edits before each change.
+	GetPostForEditQuery = `SELECT user_id, content, COALESCE(image, ''), privacy, COALESCE(group_id, 0) FROM posts WHERE id = ?`
+	UpdatePostQuery     = `UPDATE posts SET content = ?, image = ?, privacy = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	InsertPostEditQuery = `INSERT INTO post_edits (post_id, content, image, privacy) VALUES (?, ?, ?, ?)`
+	GetPostEditsQuery   = `
+		SELECT id, content, COALESCE(image, ''), privacy, edited_at
+		FROM post_edits WHERE post_id = ?
+		ORDER BY id DESC`
+	GetPostEditImagesQuery = `SELECT DISTINCT image FROM post_edits WHERE post_id = ? AND image != ''`
+	// Whether a user may see a post: group posts need membership, other posts
+	// follow the same privacy rules as the feed
+	CanViewPostQuery = `
+		SELECT EXISTS(
+			SELECT 1 FROM posts p
+			WHERE p.id = ? AND (
+				p.user_id = ? OR
+				(p.group_id IS NOT NULL AND EXISTS(
+					SELECT 1 FROM group_members WHERE group_id = p.group_id AND user_id = ?
+				)) OR
+				(p.group_id IS NULL AND (
+					p.privacy = 'public' OR
+					(p.privacy = 'followers' AND EXISTS(
+						SELECT 1 FROM follows
+						WHERE follower_id = ? AND following_id = p.user_id AND status = 'accepted'
+					)) OR
+					(p.privacy = 'private' AND EXISTS(
+						SELECT 1 FROM post_viewers WHERE post_id = p.id AND user_id = ?
+					))
+				))
+			)
+		)`
+
+	// Posts are deleted together with everything that points at them
+	DeletePostEditsQuery    = `DELETE FROM post_edits WHERE post_id = ?`
+	DeletePostCommentsQuery = `DELETE FROM post_comments WHERE post_id = ?`
+	DeletePostLikesQuery    = `DELETE FROM post_likes WHERE post_id = ?`
+	DeletePostViewersQuery  = `DELETE FROM post_viewers WHERE post_id = ?`
+	DeletePostQuery         = `DELETE FROM posts WHERE id = ?`
+
 	// Like queries
 	InsertLikeQuery = `INSERT INTO post_likes (post_id, user_id) VALUES (?, ?)`
 	DeleteLikeQuery = `DELETE FROM post_likes WHERE post_id = ? AND user_id = ?`
 
 	// Comment queries
-	InsertCommentQuery     = `INSERT INTO post_comments (post_id, user_id, content) VALUES (?, ?, ?)`
+	InsertCommentQuery = `INSERT INTO post_comments (post_id, user_id, content, parent_id) VALUES (?, ?, ?, NULLIF(?, 0))`
+	// One page of a post's comments with the given parent (0 for top-level
+	// comments), oldest first, starting after the cursor ID
 	GetCommentsByPostQuery = `
 		SELECT 
 			c.id, c.post_id, c.user_id, c.content, c.created_at,
-			COALESCE(u.nickname, u.first_name || ' ' || u.last_name) as username
+			COALESCE(u.nickname, u.first_name || ' ' || u.last_name) as username,
+			COALESCE(c.parent_id, 0), c.updated_at IS NOT NULL,
+			(SELECT COUNT(*) FROM post_comments r WHERE r.parent_id = c.id) as reply_count
 		FROM post_comments c
 		INNER JOIN users u ON c.user_id = u.id
-		WHERE c.post_id = ?
-		ORDER BY c.created_at ASC`
+		WHERE c.post_id = ? AND COALESCE(c.parent_id, 0) = ? AND c.id > ?
+		ORDER BY c.id ASC
+		LIMIT ?`
+	GetCommentForEditQuery = `
+		SELECT c.user_id, c.post_id, p.user_id
+		FROM post_comments c
+		INNER JOIN posts p ON p.id = c.post_id
+		WHERE c.id = ?`
+	UpdateCommentQuery = `UPDATE post_comments SET content = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	// Deletes a comment together with all replies below it
+	DeleteCommentThreadQuery = `
+		DELETE FROM post_comments WHERE id IN (
+			WITH RECURSIVE thread(id) AS (
+				SELECT ?
+				UNION ALL
+				SELECT c.id FROM post_comments c INNER JOIN thread t ON c.parent_id = t.id
+			)
+			SELECT id FROM thread
+		)`
 
 	// Profile queries
 	GetUserProfileQuery = `
@@ -132,7 +213,7 @@ const (
 		INNER JOIN users u ON p.user_id = u.id
 		LEFT JOIN post_comments c ON p.id = c.post_id
 		LEFT JOIN post_likes l ON p.id = l.post_id
-		WHERE p.user_id = ? AND (
+		WHERE p.user_id = ? AND p.group_id IS NULL AND (
 			p.privacy = 'public' OR
 			p.user_id = ? OR
 			(p.privacy = 'followers' AND EXISTS(
@@ -165,13 +246,27 @@ const (
 		FROM users u
 		INNER JOIN follows f ON u.id = f.following_id
 		WHERE f.follower_id = ? AND f.status = 'accepted'`
+	// Presence lookups take the user IDs as one JSON array such as [1,2,3]
+	GetLastSeenQuery = `SELECT id, last_seen_at FROM users WHERE id IN (SELECT value FROM json_each(?))`
+	// Viewers see the presence of themselves, users they follow and public accounts
+	GetPresenceAudienceQuery = `
+		SELECT u.id FROM users u
+		WHERE u.id IN (SELECT value FROM json_each(?)) AND (
+			u.id = ? OR
+			COALESCE(u.is_private, 0) = 0 OR
+			EXISTS(SELECT 1 FROM follows WHERE follower_id = ? AND following_id = u.id AND status = 'accepted')
+		)`
+	GetFollowerIDsQuery      = `SELECT follower_id FROM follows WHERE following_id = ? AND status = 'accepted'`
+	UpdateLastSeenQuery      = `UPDATE users SET last_seen_at = CURRENT_TIMESTAMP WHERE id = ?`
 	DeleteFollowRequestQuery = `DELETE FROM follows WHERE follower_id = ? AND following_id = ? AND status = 'pending'`
 	DeleteFollowerQuery      = `DELETE FROM follows WHERE follower_id = ? AND following_id = ?`
-	InsertGroupQuery         = `INSERT INTO groups (title, description, creator_id, image) VALUES (?, ?, ?, ?)`
+	InsertGroupQuery         = `INSERT INTO groups (title, description, creator_id, image, visibility) VALUES (?, ?, ?, ?, ?)`
 	InsertGroupMemberQuery   = `INSERT INTO group_members (user_id, group_id) VALUES (?, ?)`
 
+	InsertGroupOwnerQuery = `INSERT INTO group_members (user_id, group_id, role) VALUES (?, ?, 'owner')`
+
 	GetGroupMembersQuery = `
-	SELECT g.id, g.title, g.description, g.image
+	SELECT g.id, g.title, g.description, g.image, gm.role
 	FROM groups g
 	INNER JOIN group_members gm ON g.id = gm.group_id
 	WHERE gm.user_id = ?
@@ -225,14 +320,29 @@ WHERE
   AND gi.status = 'pending';
 `
 
-	UpdateInvitationQuery = `UPDATE group_invitations SET status = ? WHERE id = ?`
-	UpdateRequestQuery    = `UPDATE group_join_requests SET status = ? WHERE id = ?`
+	// Invitations and join requests only ever leave the pending state
+	ResolveInvitationQuery        = `UPDATE group_invitations SET status = ?, responded_at = CURRENT_TIMESTAMP WHERE id = ? AND status = 'pending'`
+	ResolveJoinRequestQuery       = `UPDATE group_join_requests SET status = ?, responded_at = CURRENT_TIMESTAMP WHERE id = ? AND status = 'pending'`
+	AcceptPendingInvitationsQuery = `
+		UPDATE group_invitations SET status = 'accepted', responded_at = CURRENT_TIMESTAMP
+		WHERE group_id = ? AND invited_user_id = ? AND status = 'pending'`
+	AcceptPendingJoinRequestsQuery = `
+		UPDATE group_join_requests SET status = 'accepted', responded_at = CURRENT_TIMESTAMP
+		WHERE group_id = ? AND user_id = ? AND status = 'pending'`
+	ExpireInvitationsQuery = `
+		UPDATE group_invitations SET status = 'expired', responded_at = CURRENT_TIMESTAMP
+		WHERE status = 'pending' AND created_at < datetime('now', '-30 days')`
+	ExpireJoinRequestsQuery = `
+		UPDATE group_join_requests SET status = 'expired', responded_at = CURRENT_TIMESTAMP
+		WHERE status = 'pending' AND created_at < datetime('now', '-30 days')`
+	InsertGroupMemberIfMissingQuery = `INSERT OR IGNORE INTO group_members (user_id, group_id) VALUES (?, ?)`
+	GetInvitationSenderQuery        = `SELECT invited_by_user_id FROM group_invitations WHERE id = ?`
 
 	GetPublicGroupQuery = `SELECT g.id, g.creator_id, g.title, g.description, g.image
 FROM groups g
 LEFT JOIN group_members gm ON gm.group_id = g.id AND gm.user_id = ?
-LEFT JOIN group_join_requests gjr ON gjr.group_id = g.id AND gjr.user_id = ?
-WHERE gm.user_id IS NULL AND gjr.user_id IS NULL
+LEFT JOIN group_join_requests gjr ON gjr.group_id = g.id AND gjr.user_id = ? AND gjr.status = 'pending'
+WHERE gm.user_id IS NULL AND gjr.user_id IS NULL AND g.visibility != 'secret'
 `
 
 	InsertGroupRequestQuery = `INSERT INTO group_join_requests (user_id, group_id)
@@ -244,8 +354,9 @@ VALUES (?, ?);`
 			group_join_requests r
 		JOIN users u ON r.user_id = u.id
 		JOIN groups g ON r.group_id = g.id
+		JOIN group_members gm ON gm.group_id = g.id AND gm.user_id = ?
 		WHERE 
-			g.creator_id = ? AND r.status = 'pending'
+			gm.role IN ('owner', 'admin') AND r.status = 'pending'
 	`
 	IsPrivateUserQuery = "SELECT is_private FROM users WHERE id = ?"
 
@@ -253,30 +364,250 @@ VALUES (?, ?);`
 
 	InsertMessage = `INSERT INTO MESSAGES (message_content, sender_id, receiver_id) VALUES (?, ?, ?)`
 
-		InsertEventQuery   = `INSERT INTO group_events (group_id, creator_id, title, description, event_time, age) VALUES (?, ?, ? , ?, ?, ?) `
+	// A private message is allowed when the receiver is public or both users follow each other
+	CanMessageQuery = `
+		SELECT EXISTS(
+			SELECT 1 FROM users u
+			WHERE u.id = ? AND (
+				COALESCE(u.is_private, 0) = 0 OR (
+					EXISTS(SELECT 1 FROM follows WHERE follower_id = ? AND following_id = u.id AND status = 'accepted') AND
+					EXISTS(SELECT 1 FROM follows WHERE follower_id = u.id AND following_id = ? AND status = 'accepted')
+				)
+			)
+		)`
+
+	// Latest message per conversation partner, newest conversation first
+	GetConversationsQuery = `
+		SELECT 
+			c.partner_id, u.nickname, u.first_name, u.last_name, u.image,
+			m.message_id, m.sender_id, m.receiver_id, m.message_content, m.created_at,
+			(SELECT COUNT(*) FROM MESSAGES 
+			 WHERE sender_id = c.partner_id AND receiver_id = ? AND is_read = 0) as unread_count
+		FROM (
+			SELECT 
+				CASE WHEN sender_id = ? THEN receiver_id ELSE sender_id END as partner_id,
+				MAX(message_id) as last_id
+			FROM MESSAGES
+			WHERE sender_id = ? OR receiver_id = ?
+			GROUP BY partner_id
+		) c
+		INNER JOIN MESSAGES m ON m.message_id = c.last_id
+		INNER JOIN users u ON u.id = c.partner_id
+		ORDER BY m.message_id DESC`
+
+	// Messages between two users older than the cursor (0 = from the newest)
+	GetMessageHistoryQuery = `
+		SELECT message_id, sender_id, receiver_id, message_content, created_at
+		FROM MESSAGES
+		WHERE ((sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?))
+		  AND (? = 0 OR message_id < ?)
+		ORDER BY message_id DESC
+		LIMIT ?`
+
+	GetLastUnreadMessageIDQuery = `SELECT COALESCE(MAX(message_id), 0) FROM MESSAGES WHERE sender_id = ? AND receiver_id = ? AND is_read = 0`
+	MarkMessagesReadQuery       = `UPDATE MESSAGES SET is_read = 1 WHERE sender_id = ? AND receiver_id = ? AND is_read = 0 AND message_id <= ?`
+	GetUnreadMessageCountQuery  = `SELECT COUNT(*) FROM MESSAGES WHERE receiver_id = ? AND is_read = 0`
+
+	InsertGroupMessageQuery = `INSERT INTO group_messages (group_id, sender_id, content) VALUES (?, ?, ?)`
+	IsGroupMemberQuery      = `SELECT EXISTS(SELECT 1 FROM group_members WHERE user_id = ? AND group_id = ?)`
+	GetUserGroupIDsQuery    = `SELECT group_id FROM group_members WHERE user_id = ?`
+
+	// Answering again replaces the previous answer to the same occurrence
 	EventResponseQuery = `
-		INSERT INTO event_responses (event_id, user_id, group_id, response)
-		VALUES (?, ?, ?, ?);
-		
-	`
-	GetGroupEventsQuery = `SELECT e.id, e.group_id, e.creator_id, e.title, e.description, e.age, e.event_time
-FROM group_events e
-WHERE e.group_id = ?
-  AND NOT EXISTS (
-    SELECT 1 FROM event_responses r
-    WHERE r.event_id = e.id AND r.user_id = ?
-  )
-`
-	GetGoingEventQuery = `SELECT e.id, e.group_id, e.creator_id, e.title, e.description, e.age, e.event_time
-FROM group_events e
-JOIN event_responses r ON r.event_id = e.id
-WHERE e.group_id = ?
-  AND r.user_id = ?
-  AND r.response = 'going'
-  ORDER BY e.event_time ASC;
-`
-	InsertEventResponse = `
-		INSERT INTO event_responses (event_id, user_id, group_id, response)
-		VALUES (?, ?, ?, ?)
-	`
+		INSERT INTO event_responses (event_id, user_id, group_id, occurrence, response)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (event_id, user_id, occurrence) DO UPDATE
+		SET response = excluded.response, responded_at = CURRENT_TIMESTAMP`
+	// Event queries select the columns read by scanEvent, optionally followed by more.
+	// Recurring events come back once and are expanded into occurrences in Go.
+	GetGroupEventsQuery = `
+		SELECT e.id, e.group_id, e.creator_id, e.title, COALESCE(e.description, ''), e.age, e.event_time,
+			COALESCE(e.recurrence, ''), COALESCE(e.recurrence_until, ''), COALESCE(e.recurrence_count, 0)
+		FROM group_events e
+		WHERE e.group_id = ? AND e.status = 'scheduled' AND e.age <= ?`
+	GetGoingEventQuery = `
+		SELECT e.id, e.group_id, e.creator_id, e.title, COALESCE(e.description, ''), e.age, e.event_time,
+			COALESCE(e.recurrence, ''), COALESCE(e.recurrence_until, ''), COALESCE(e.recurrence_count, 0),
+			r.occurrence
+		FROM group_events e
+		JOIN event_responses r ON r.event_id = e.id
+		WHERE e.group_id = ? AND e.status = 'scheduled' AND e.age <= ?
+			AND r.user_id = ?
+			AND r.response = 'going'`
+	GetUserGroupEventResponsesQuery = `
+		SELECT r.event_id, r.occurrence, r.response
+		FROM event_responses r
+		INNER JOIN group_events e ON e.id = r.event_id
+		WHERE e.group_id = ? AND r.user_id = ?`
+	GetGroupCancelledOccurrencesQuery = `
+		SELECT c.event_id, c.occurrence
+		FROM event_occurrence_cancellations c
+		INNER JOIN group_events e ON e.id = c.event_id
+		WHERE e.group_id = ?`
+	IsOccurrenceCancelledQuery = `SELECT EXISTS(SELECT 1 FROM event_occurrence_cancellations WHERE event_id = ? AND occurrence = ?)`
+	CancelOccurrenceQuery      = `INSERT INTO event_occurrence_cancellations (event_id, occurrence, cancelled_by) VALUES (?, ?, ?)`
+	BumpEventSequenceQuery     = `UPDATE group_events SET sequence = sequence + 1 WHERE id = ?`
+	// Going answers to occurrences that may still need a reminder
+	GetReminderCandidatesQuery = `
+		SELECT e.id, e.group_id, e.creator_id, e.title, COALESCE(e.description, ''), e.age, e.event_time,
+			COALESCE(e.recurrence, ''), COALESCE(e.recurrence_until, ''), COALESCE(e.recurrence_count, 0),
+			r.occurrence, r.user_id
+		FROM event_responses r
+		INNER JOIN group_events e ON e.id = r.event_id
+		INNER JOIN group_members m ON m.group_id = e.group_id AND m.user_id = r.user_id
+		LEFT JOIN event_occurrence_cancellations c ON c.event_id = e.id AND c.occurrence = r.occurrence
+		WHERE r.response = 'going' AND e.status = 'scheduled' AND c.event_id IS NULL
+			AND (e.recurrence IS NOT NULL OR datetime(e.event_time) > datetime('now'))`
+	InsertEventReminderQuery = `
+		INSERT OR IGNORE INTO event_reminders (event_id, occurrence, user_id, offset_seconds)
+		VALUES (?, ?, ?, ?)`
+	ClearEventRemindersQuery = `DELETE FROM event_reminders WHERE event_id = ?`
+	GetUserDateOfBirthQuery  = `SELECT strftime('%Y-%m-%d', date_of_birth) FROM users WHERE id = ?`
+	GetEventQuery            = `
+		SELECT id, group_id, creator_id, title, COALESCE(description, ''), age, event_time,
+			COALESCE(recurrence, ''), COALESCE(recurrence_until, ''), COALESCE(recurrence_count, 0), status
+		FROM group_events WHERE id = ?`
+	InsertEventQuery = `
+		INSERT INTO group_events (group_id, creator_id, title, description, event_time, age, recurrence, recurrence_until, recurrence_count)
+		VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, 0))`
+	UpdateEventQuery         = `UPDATE group_events SET title = ?, description = ?, event_time = ?, age = ?, sequence = sequence + 1 WHERE id = ?`
+	CancelEventQuery         = `UPDATE group_events SET status = 'cancelled', sequence = sequence + 1 WHERE id = ? AND status = 'scheduled'`
+	GetEventAttendeeIDsQuery = `SELECT DISTINCT user_id FROM event_responses WHERE event_id = ? AND response IN ('going', 'maybe')`
+	// Same as GetEventAttendeeIDsQuery for a single occurrence
+	GetOccurrenceAttendeeIDsQuery = `
+		SELECT user_id FROM event_responses
+		WHERE event_id = ? AND occurrence = ? AND response IN ('going', 'maybe')`
+	GetEventAttendeesQuery = `
+		SELECT u.id, COALESCE(u.nickname, ''), u.first_name, u.last_name, COALESCE(u.image, ''), r.response
+		FROM event_responses r
+		INNER JOIN users u ON r.user_id = u.id
+		WHERE r.event_id = ? AND r.occurrence = ?
+		ORDER BY CASE r.response WHEN 'going' THEN 0 WHEN 'maybe' THEN 1 ELSE 2 END, r.responded_at ASC`
+
+	// Calendar export. Cancelled events stay in the feed so calendar apps
+	// can mark their copy as cancelled instead of keeping a stale entry.
+	GetCalendarEventQuery = `
+		SELECT e.id, e.title, COALESCE(e.description, ''), e.event_time, COALESCE(e.recurrence, ''),
+			CASE WHEN c.event_id IS NULL THEN e.status ELSE 'cancelled' END, e.sequence, g.title
+		FROM group_events e
+		INNER JOIN groups g ON g.id = e.group_id
+		LEFT JOIN event_occurrence_cancellations c ON c.event_id = e.id AND c.occurrence = ?
+		WHERE e.id = ?`
+	GetCalendarFeedEventsQuery = `
+		SELECT e.id, e.title, COALESCE(e.description, ''), e.event_time, COALESCE(e.recurrence, ''),
+			CASE WHEN c.event_id IS NULL THEN e.status ELSE 'cancelled' END, e.sequence, g.title,
+			r.occurrence
+		FROM group_events e
+		INNER JOIN groups g ON g.id = e.group_id
+		INNER JOIN group_members m ON m.group_id = e.group_id AND m.user_id = ?
+		INNER JOIN event_responses r ON r.event_id = e.id AND r.user_id = m.user_id
+		LEFT JOIN event_occurrence_cancellations c ON c.event_id = e.id AND c.occurrence = r.occurrence
+		WHERE r.response = 'going' AND e.age <= ?
+		ORDER BY datetime(e.event_time) ASC, r.occurrence ASC`
+	GetCalendarTokenQuery     = `SELECT token FROM calendar_tokens WHERE user_id = ?`
+	GetCalendarTokenUserQuery = `SELECT user_id FROM calendar_tokens WHERE token = ?`
+	UpsertCalendarTokenQuery  = `
+		INSERT INTO calendar_tokens (user_id, token) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET token = excluded.token, created_at = CURRENT_TIMESTAMP`
+
+	// Notification queries
+	InsertNotificationQuery = `
+		INSERT INTO notifications (user_id, actor_id, type, group_id, reference_id, message)
+		VALUES (?, ?, ?, NULLIF(?, 0), NULLIF(?, 0), ?)`
+	GetNotificationActorQuery = `SELECT COALESCE(nickname, first_name || ' ' || last_name), image FROM users WHERE id = ?`
+	GetGroupTitleQuery        = `SELECT title FROM groups WHERE id = ?`
+	GetNotificationsQuery     = `
+		SELECT 
+			n.id, n.user_id, n.actor_id, 
+			COALESCE(u.nickname, u.first_name || ' ' || u.last_name) as actor_name,
+			u.image, n.type, COALESCE(n.group_id, 0), COALESCE(n.reference_id, 0),
+			n.message, n.is_read, n.created_at
+		FROM notifications n
+		INNER JOIN users u ON n.actor_id = u.id
+		WHERE n.user_id = ? AND (? = 0 OR n.id < ?)
+		ORDER BY n.id DESC
+		LIMIT ?`
+	GetUnreadNotificationCountQuery = `SELECT COUNT(*) FROM notifications WHERE user_id = ? AND is_read = 0`
+	MarkNotificationReadQuery       = `UPDATE notifications SET is_read = 1 WHERE id = ? AND user_id = ?`
+	MarkAllNotificationsReadQuery   = `UPDATE notifications SET is_read = 1 WHERE user_id = ? AND is_read = 0`
+
+	GetInvitationQuery      = `SELECT group_id, invited_user_id FROM group_invitations WHERE id = ?`
+	GetJoinRequestQuery     = `SELECT user_id, group_id FROM group_join_requests WHERE id = ?`
+	GetGroupMemberIDsQuery  = `SELECT user_id FROM group_members WHERE group_id = ?`
+	GetGroupManagerIDsQuery = `SELECT user_id FROM group_members WHERE group_id = ? AND role IN ('owner', 'admin')`
+
+	// Role queries
+	GetMemberRoleQuery      = `SELECT role FROM group_members WHERE user_id = ? AND group_id = ?`
+	UpdateMemberRoleQuery   = `UPDATE group_members SET role = ? WHERE user_id = ? AND group_id = ?`
+	UpdateGroupCreatorQuery = `UPDATE groups SET creator_id = ? WHERE id = ?`
+	DeleteGroupMemberQuery  = `DELETE FROM group_members WHERE user_id = ? AND group_id = ?`
+	// Next owner when the owner leaves: highest role first, then longest membership
+	GetGroupSuccessorQuery = `
+		SELECT user_id FROM group_members
+		WHERE group_id = ? AND user_id != ?
+		ORDER BY CASE role WHEN 'admin' THEN 1 WHEN 'moderator' THEN 2 ELSE 3 END, joined_at ASC
+		LIMIT 1`
+
+	// Member list and bans
+	GetGroupMemberListQuery = `
+		SELECT u.id, COALESCE(u.nickname, ''), u.first_name, u.last_name, COALESCE(u.image, ''), gm.role, gm.joined_at
+		FROM group_members gm
+		INNER JOIN users u ON gm.user_id = u.id
+		WHERE gm.group_id = ?
+		ORDER BY CASE gm.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 WHEN 'moderator' THEN 2 ELSE 3 END, gm.joined_at ASC`
+	IsGroupBannedQuery  = `SELECT EXISTS(SELECT 1 FROM group_bans WHERE group_id = ? AND user_id = ?)`
+	InsertGroupBanQuery = `INSERT OR IGNORE INTO group_bans (group_id, user_id, banned_by) VALUES (?, ?, ?)`
+	DeleteGroupBanQuery = `DELETE FROM group_bans WHERE group_id = ? AND user_id = ?`
+	GetGroupBansQuery   = `
+		SELECT u.id, COALESCE(u.nickname, ''), u.first_name, u.last_name, COALESCE(u.image, ''), b.created_at
+		FROM group_bans b
+		INNER JOIN users u ON b.user_id = u.id
+		WHERE b.group_id = ?
+		ORDER BY b.created_at DESC`
+	DeclinePendingInvitationsQuery  = `UPDATE group_invitations SET status = 'declined', responded_at = CURRENT_TIMESTAMP WHERE group_id = ? AND invited_user_id = ? AND status = 'pending'`
+	DeclinePendingJoinRequestsQuery = `UPDATE group_join_requests SET status = 'declined', responded_at = CURRENT_TIMESTAMP WHERE group_id = ? AND user_id = ? AND status = 'pending'`
+
+	// Group page and settings
+	GetGroupByIDQuery            = `SELECT id, title, COALESCE(description, ''), COALESCE(image, ''), creator_id, visibility FROM groups WHERE id = ?`
+	GetGroupMemberCountQuery     = `SELECT COUNT(*) FROM group_members WHERE group_id = ?`
+	GetPendingJoinRequestIDQuery = `SELECT id FROM group_join_requests WHERE group_id = ? AND user_id = ? AND status = 'pending' ORDER BY id DESC LIMIT 1`
+	GetPendingInvitationIDQuery  = `SELECT id FROM group_invitations WHERE group_id = ? AND invited_user_id = ? AND status = 'pending' ORDER BY id DESC LIMIT 1`
+	// Events with an occurrence still to come; recurring ones are expanded and limited in Go
+	GetUpcomingGroupEventsQuery = `
+		SELECT id, group_id, creator_id, title, COALESCE(description, ''), age, event_time,
+			COALESCE(recurrence, ''), COALESCE(recurrence_until, ''), COALESCE(recurrence_count, 0)
+		FROM group_events
+		WHERE group_id = ? AND status = 'scheduled' AND age <= ?
+			AND (recurrence IS NOT NULL OR datetime(event_time) >= datetime('now'))`
+	UpdateGroupQuery = `UPDATE groups SET title = ?, description = ?, image = ?, visibility = ? WHERE id = ?`
+
+	// Group directory: listed groups matching an optional search, with keyset
+	// pagination on the sort column and the group ID as a tie-breaker
+	GetGroupDirectoryByMembersQuery = groupDirectoryQuery + `
+		AND (? = 0 OR member_count < ? OR (member_count = ? AND id < ?))
+		ORDER BY member_count DESC, id DESC
+		LIMIT ?`
+	GetGroupDirectoryByActivityQuery = groupDirectoryQuery + `
+		AND (? = 0 OR last_activity < ? OR (last_activity = ? AND id < ?))
+		ORDER BY last_activity DESC, id DESC
+		LIMIT ?`
+	GetGroupVisibilityQuery = `SELECT visibility FROM groups WHERE id = ?`
 )
+
+const groupDirectoryQuery = `
+	WITH directory AS (
+		SELECT
+			g.id, g.title, COALESCE(g.description, '') AS description, COALESCE(g.image, '') AS image,
+			g.creator_id, g.visibility,
+			(SELECT COUNT(*) FROM group_members WHERE group_id = g.id) AS member_count,
+			MAX(
+				COALESCE((SELECT MAX(created_at) FROM posts WHERE group_id = g.id), g.created_at),
+				COALESCE((SELECT MAX(created_at) FROM group_events WHERE group_id = g.id), g.created_at)
+			) AS last_activity,
+			EXISTS(SELECT 1 FROM group_members WHERE group_id = g.id AND user_id = ?) AS is_member
+		FROM groups g
+		WHERE ? = '' OR g.title LIKE ? ESCAPE '\' OR g.description LIKE ? ESCAPE '\'
+	)
+	SELECT id, title, description, image, creator_id, visibility, member_count, last_activity, is_member
+	FROM directory
+	WHERE (visibility != 'secret' OR is_member)`
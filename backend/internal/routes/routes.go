@@ -3,16 +3,26 @@ package routes
 import (
 	"net/http"
 	"social-network/internal/auth"
+	"social-network/internal/config"
 	"social-network/internal/groups"
+	"social-network/internal/messages"
+	"social-network/internal/notifications"
 	"social-network/internal/posts"
 	"social-network/internal/sessions"
 	"social-network/internal/users"
+	"social-network/internal/utils"
 	"social-network/internal/websocket"
 )
 
 
 
-func RegisterRoutes(mux *http.ServeMux, manager *websocket.Manager) {
+func RegisterRoutes(mux *http.ServeMux, manager *websocket.Manager, cfg *config.Config) {
+	sameSite, _ := cfg.SameSiteMode() // validated when the config was loaded
+	sessions.Configure(cfg.SessionTTL.Duration, cfg.CookieSecure, sameSite)
+	utils.SetAllowedOrigins(cfg.AllowedOrigins)
+	utils.ConfigureUploads(cfg.UploadDir, cfg.MaxUploadSize)
+	notifications.SetPusher(manager)
+	groups.SetRoomLeaver(manager)
 
 	mux.HandleFunc("/ws", websocket.WebSocketHandler(manager))
 	// Authentication routes
@@ -25,14 +35,24 @@ func RegisterRoutes(mux *http.ServeMux, manager *websocket.Manager) {
 	mux.HandleFunc("/posts", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
+			if r.URL.Query().Has("id") {
+				posts.HandleGetPost(w, r)
+				return
+			}
 			posts.HandleGetPosts(w, r)
 		case http.MethodPost:
 			posts.HandleCreatePost(w, r)
+		case http.MethodPut:
+			posts.HandleUpdatePost(w, r)
+		case http.MethodDelete:
+			posts.HandleDeletePost(w, r)
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
 
+	mux.HandleFunc("/posts/history", posts.HandleGetPostHistory)
+
 	// Like/Unlike routes
 	mux.HandleFunc("/posts/like", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -52,13 +72,31 @@ func RegisterRoutes(mux *http.ServeMux, manager *websocket.Manager) {
 			posts.HandleGetComments(w, r)
 		case http.MethodPost:
 			posts.HandleCreateComment(w, r)
+		case http.MethodPut:
+			posts.HandleUpdateComment(w, r)
+		case http.MethodDelete:
+			posts.HandleDeleteComment(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Group feed routes
+	mux.HandleFunc("/groups/posts", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			posts.HandleGetGroupPosts(w, r)
+		case http.MethodPost:
+			posts.HandleCreateGroupPost(w, r)
+		case http.MethodDelete:
+			posts.HandleDeleteGroupPost(w, r)
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
 
 	// Static file server for uploaded images
-	fileServer := http.FileServer(http.Dir("./uploads"))
+	fileServer := http.FileServer(http.Dir(cfg.UploadDir))
 	mux.Handle("/uploads/", http.StripPrefix("/uploads/", fileServer))
 
 	// Profile routes
@@ -77,23 +115,53 @@ func RegisterRoutes(mux *http.ServeMux, manager *websocket.Manager) {
 	mux.HandleFunc("/follow/requests", users.HandleGetFollowRequests)
 
 // Group Routes
-	mux.HandleFunc("/groups/creategroups", groups.CreateGroup)
-	mux.HandleFunc("/groups/user-groups", groups.GetUserGroups)
-	mux.HandleFunc("/groups/search-users", groups.SearchUsers)
-	mux.HandleFunc("/groups/invite-to-group", groups.GroupInvitation)
-	mux.HandleFunc("/groups/user-invitations", groups.GetUserInvitations)
-	mux.HandleFunc("/groups/respond-invitation", groups.InvitationResponse)
-	mux.HandleFunc("/groups/public", groups.GetPublicGroupsHandler)
-	mux.HandleFunc("/groups/request-join", groups.InsertGroupRequests)
-	mux.HandleFunc("/groups/get-join-requests", groups.GetJoinRequestsToCreator)
-	mux.HandleFunc("/groups/respond-requests", groups.RequestResponse)
-	mux.HandleFunc("/groups/groups-event", groups.CreateGroupEvent)
-	mux.HandleFunc("/groups/events", groups.GetGroupEvents)
-	mux.HandleFunc("/groups/going_events", groups.GetGoingEvents)
-	mux.HandleFunc("/groups/event-response", groups.EventResponse)
+	mux.HandleFunc("/groups/creategroups", sessions.RequireAuth(groups.CreateGroup))
+	mux.HandleFunc("/groups/user-groups", sessions.RequireAuth(groups.GetUserGroups))
+	mux.HandleFunc("/groups/search-users", sessions.RequireAuth(groups.SearchUsers))
+	mux.HandleFunc("/groups/invite-to-group", sessions.RequireAuth(groups.GroupInvitation))
+	mux.HandleFunc("/groups/user-invitations", sessions.RequireAuth(groups.GetUserInvitations))
+	mux.HandleFunc("/groups/respond-invitation", sessions.RequireAuth(groups.InvitationResponse))
+	mux.HandleFunc("/groups/public", sessions.RequireAuth(groups.GetPublicGroupsHandler))
+	mux.HandleFunc("/groups/request-join", sessions.RequireAuth(groups.InsertGroupRequests))
+	mux.HandleFunc("/groups/get-join-requests", sessions.RequireAuth(groups.GetJoinRequestsToCreator))
+	mux.HandleFunc("/groups/respond-requests", sessions.RequireAuth(groups.RequestResponse))
+	mux.HandleFunc("/groups/groups-event", sessions.RequireAuth(groups.CreateGroupEvent))
+	mux.HandleFunc("/groups/events", sessions.RequireAuth(groups.GetGroupEvents))
+	mux.HandleFunc("/groups/going_events", sessions.RequireAuth(groups.GetGoingEvents))
+	mux.HandleFunc("/groups/event-response", sessions.RequireAuth(groups.EventResponse))
+	mux.HandleFunc("/groups/events/attendees", sessions.RequireAuth(groups.GetEventAttendees))
+	mux.HandleFunc("/groups/events/update", sessions.RequireAuth(groups.UpdateGroupEvent))
+	mux.HandleFunc("/groups/events/cancel", sessions.RequireAuth(groups.CancelGroupEvent))
+	mux.HandleFunc("/groups/events/ics", sessions.RequireAuth(groups.ExportGroupEvent))
+	mux.HandleFunc("/groups/calendar/token", sessions.RequireAuth(groups.CalendarToken))
+	mux.HandleFunc("/groups/calendar/feed", groups.CalendarFeed)
+	mux.HandleFunc("/groups/members/role", sessions.RequireAuth(groups.UpdateMemberRole))
+	mux.HandleFunc("/groups/members/remove", sessions.RequireAuth(groups.RemoveMember))
+	mux.HandleFunc("/groups/transfer-ownership", sessions.RequireAuth(groups.TransferOwnership))
+	mux.HandleFunc("/groups/leave", sessions.RequireAuth(groups.LeaveGroup))
+	mux.HandleFunc("/groups/members", sessions.RequireAuth(groups.GetGroupMembers))
+	mux.HandleFunc("/groups/members/ban", sessions.RequireAuth(groups.BanMember))
+	mux.HandleFunc("/groups/members/unban", sessions.RequireAuth(groups.UnbanMember))
+	mux.HandleFunc("/groups/bans", sessions.RequireAuth(groups.GetGroupBans))
+	mux.HandleFunc("/groups/details", sessions.RequireAuth(groups.GetGroup))
+	mux.HandleFunc("/groups/update", sessions.RequireAuth(groups.UpdateGroup))
+	mux.HandleFunc("/groups/directory", sessions.RequireAuth(groups.GetGroupDirectory))
+	mux.HandleFunc("/groups/cancel-invitation", sessions.RequireAuth(groups.CancelInvitation))
+	mux.HandleFunc("/groups/cancel-request", sessions.RequireAuth(groups.CancelJoinRequest))
+
+	// Message routes
+	mux.HandleFunc("/messages/conversations", messages.HandleGetConversations)
+	mux.HandleFunc("/messages/history", messages.HandleGetHistory)
+	mux.HandleFunc("/messages/read", websocket.MarkReadHandler(manager))
+
+	// Notification routes
+	mux.HandleFunc("/notifications", notifications.HandleGetNotifications)
+	mux.HandleFunc("/notifications/read", notifications.HandleMarkRead)
+	mux.HandleFunc("/notifications/read-all", notifications.HandleMarkAllRead)
 
 	// Users routes
 	mux.HandleFunc("/users", users.HandleGetUsers)
+	mux.HandleFunc("/users/online", websocket.OnlineStatusHandler(manager))
 
 	// New route for getting current user's followers (for post creation)
 	mux.HandleFunc("/my-followers", users.HandleGetMyFollowers)
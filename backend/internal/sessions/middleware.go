@@ -0,0 +1,39 @@
+package sessions
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKey string
+
+const (
+	userIDKey   contextKey = "userID"
+	usernameKey contextKey = "username"
+)
+
+// RequireAuth resolves the session once and stores the user in the request
+// context. Requests without a valid session get a 401.
+func RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, username, err := GetUserFromSession(r)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userIDKey, userID)
+		ctx = context.WithValue(ctx, usernameKey, username)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// UserFromContext returns the user stored by RequireAuth
+func UserFromContext(ctx context.Context) (int, string, bool) {
+	userID, ok := ctx.Value(userIDKey).(int)
+	if !ok || userID == 0 {
+		return 0, "", false
+	}
+	username, _ := ctx.Value(usernameKey).(string)
+	return userID, username, true
+}
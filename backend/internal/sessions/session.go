@@ -13,11 +13,22 @@ import (
 	"github.com/gofrs/uuid"
 )
 
-const (
-	SessionCookieName = "SN-session"
-	SessionDuration   = 24 * time.Hour
+const SessionCookieName = "SN-session"
+
+// Session lifetime and cookie attributes, set from the server config by Configure
+var (
+	SessionDuration = 24 * time.Hour
+	cookieSecure    = true
+	cookieSameSite  = http.SameSiteNoneMode
 )
 
+// Configure sets the session lifetime and the attributes of the session cookie
+func Configure(ttl time.Duration, secure bool, sameSite http.SameSite) {
+	SessionDuration = ttl
+	cookieSecure = secure
+	cookieSameSite = sameSite
+}
+
 func Authorization(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie(SessionCookieName)
 	if err != nil {
@@ -67,10 +78,18 @@ func Authorization(w http.ResponseWriter, r *http.Request) {
 		profilePicURL = strings.Replace(profilePic.String, "./uploads/", "/uploads/", 1)
 	}
 
+	// Unread total so message badges are correct on page load
+	var unreadMessages int
+	err = database.DB.QueryRow(queries.GetUnreadMessageCountQuery, userID).Scan(&unreadMessages)
+	if err != nil {
+		fmt.Println("Error counting unread messages:", err)
+	}
+
 	utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"name":       name,
-		"id":         userID,
-		"profilePic": profilePicURL,
+		"name":           name,
+		"id":             userID,
+		"profilePic":     profilePicURL,
+		"unreadMessages": unreadMessages,
 	})
 }
 
@@ -92,22 +111,32 @@ func GetUserFromSession(r *http.Request) (int, string, error) {
 		return 0, "", fmt.Errorf("no session cookie")
 	}
 
-	var userID int
+	userID, err := ValidateSession(cookie.Value)
+	if err != nil {
+		return 0, "", err
+	}
+
 	var username string
+	database.DB.QueryRow(queries.GetUserNameByID, userID).Scan(&username)
+
+	return userID, username, nil
+}
+
+// ValidateSession returns the user owning sessionID if it exists and has not expired
+func ValidateSession(sessionID string) (int, error) {
+	var userID int
 	var expiresAt time.Time
 
-	err = database.DB.QueryRow(queries.GetSessionQuery, cookie.Value).Scan(&userID, &expiresAt)
+	err := database.DB.QueryRow(queries.GetSessionQuery, sessionID).Scan(&userID, &expiresAt)
 	if err != nil {
-		return 0, "", fmt.Errorf("invalid session")
+		return 0, fmt.Errorf("invalid session")
 	}
 
 	if time.Now().After(expiresAt) {
-		return 0, "", fmt.Errorf("session expired")
+		return 0, fmt.Errorf("session expired")
 	}
 
-	err = database.DB.QueryRow(queries.GetUserNameByID).Scan(&username)
-
-	return userID, username, nil
+	return userID, nil
 }
 
 func HandleLogout(w http.ResponseWriter, r *http.Request) {
@@ -127,8 +156,8 @@ func SetCookie(w http.ResponseWriter, sessionID string) {
 		Path:     "/",
 		Expires:  time.Now().Add(SessionDuration),
 		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteNoneMode,
+		Secure:   cookieSecure,
+		SameSite: cookieSameSite,
 	})
 }
 
@@ -139,9 +168,9 @@ func ClearCookie(w http.ResponseWriter) {
 		Path:     "/",
 		Expires:  time.Now().Add(-1 * time.Hour),
 		HttpOnly: true,
-		Secure:   true,
+		Secure:   cookieSecure,
 		MaxAge:   -1,
-		SameSite: http.SameSiteNoneMode,
+		SameSite: cookieSameSite,
 	})
 }
 
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"social-network/internal/database"
 	"social-network/internal/models"
+	"social-network/internal/notifications"
 	"social-network/internal/queries"
 	"social-network/internal/sessions"
 	"social-network/internal/utils"
@@ -77,8 +78,14 @@ func HandleFollowRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	message := "Follow request sent"
+	notificationType := notifications.TypeFollowRequest
 	if status == "accepted" {
 		message = "Now following"
+		notificationType = notifications.TypeNewFollower
+	}
+
+	if err := notifications.Notify(req.UserID, followerID, notificationType, 0, 0); err != nil {
+		fmt.Println("Error creating follow notification:", err)
 	}
 
 	utils.SendJSONResponse(w, http.StatusOK, map[string]string{
@@ -0,0 +1,93 @@
+package users
+
+import (
+	"database/sql"
+	"encoding/json"
+	"social-network/internal/database"
+	"social-network/internal/queries"
+	"time"
+)
+
+// GetFollowerIDs returns the IDs of users with an accepted follow on userID
+func GetFollowerIDs(userID int) ([]int, error) {
+	rows, err := database.DB.Query(queries.GetFollowerIDsQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// UpdateLastSeen records the moment userID went offline
+func UpdateLastSeen(userID int) error {
+	_, err := database.DB.Exec(queries.UpdateLastSeenQuery, userID)
+	return err
+}
+
+// GetLastSeen returns the last_seen_at of each given user that has one
+func GetLastSeen(userIDs []int) (map[int]time.Time, error) {
+	lastSeen := make(map[int]time.Time)
+	if len(userIDs) == 0 {
+		return lastSeen, nil
+	}
+
+	ids, err := json.Marshal(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := database.DB.Query(queries.GetLastSeenQuery, string(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var seen sql.NullTime
+		if err := rows.Scan(&id, &seen); err != nil {
+			return nil, err
+		}
+		if seen.Valid {
+			lastSeen[id] = seen.Time
+		}
+	}
+	return lastSeen, rows.Err()
+}
+
+// GetPresenceAudience returns which of userIDs viewerID may see the online status
+// of: themselves, users they follow and anyone they could message. That is the
+// same audience that receives presence pushes, plus public accounts.
+func GetPresenceAudience(viewerID int, userIDs []int) (map[int]bool, error) {
+	visible := make(map[int]bool)
+	if len(userIDs) == 0 {
+		return visible, nil
+	}
+
+	ids, err := json.Marshal(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := database.DB.Query(queries.GetPresenceAudienceQuery, string(ids), viewerID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		visible[id] = true
+	}
+	return visible, rows.Err()
+}
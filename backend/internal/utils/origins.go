@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	allowedOrigins = map[string]bool{"http://localhost:5173": true}
+	originsMu      sync.RWMutex
+)
+
+// SetAllowedOrigins replaces the origins allowed by CORS and the WebSocket handshake
+func SetAllowedOrigins(origins []string) {
+	allowed := make(map[string]bool)
+	for _, origin := range origins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			allowed[origin] = true
+		}
+	}
+
+	originsMu.Lock()
+	allowedOrigins = allowed
+	originsMu.Unlock()
+}
+
+// IsAllowedOrigin reports whether origin is on the allow-list
+func IsAllowedOrigin(origin string) bool {
+	originsMu.RLock()
+	defer originsMu.RUnlock()
+	return allowedOrigins[origin]
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gofrs/uuid"
+)
+
+var (
+	uploadDir           = "./uploads"
+	maxUploadSize int64 = 10 << 20
+)
+
+// ConfigureUploads sets where uploaded files are stored and the largest request body accepted
+func ConfigureUploads(dir string, maxSize int64) {
+	uploadDir = dir
+	maxUploadSize = maxSize
+}
+
+// UploadDir returns the directory uploaded files are stored in
+func UploadDir() string {
+	return uploadDir
+}
+
+// ParseUploadForm parses a multipart form, rejecting bodies over the upload limit
+func ParseUploadForm(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	return r.ParseMultipartForm(maxUploadSize)
+}
+
+// SaveUpload stores an uploaded file under a random name and returns that name
+func SaveUpload(file multipart.File, header *multipart.FileHeader) (string, error) {
+	filename := uuid.Must(uuid.NewV4()).String() + filepath.Ext(header.Filename)
+
+	dst, err := os.Create(filepath.Join(uploadDir, filename))
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, file); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// RemoveUpload deletes a stored upload given the path saved in the database
+// ("./uploads/<name>" or "/uploads/<name>"). A file that is already gone is not an error.
+func RemoveUpload(path string) error {
+	if path == "" {
+		return nil
+	}
+	err := os.Remove(filepath.Join(uploadDir, filepath.Base(path)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -3,28 +3,38 @@ package websocket
 import (
 	"fmt"
 	"log"
+	"social-network/internal/sessions"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// How often an open connection re-checks that its session is still valid
+const sessionCheckInterval = 1 * time.Minute
+
 type Client struct {
-	conn     *websocket.Conn
-	manager  *Manager
-	egress   chan []byte
-	userID   int
-	username string
-	activeChats map[int]bool
+	conn        *websocket.Conn
+	manager     *Manager
+	egress      chan []byte
+	done        chan struct{} // closed once WriteMessages has returned
+	userID      int
+	username    string
+	sessionID   string
+	activeChats map[int]bool // open conversations, guarded by the manager lock
+	rooms       map[int]bool // group rooms, guarded by the manager lock
 }
 
-func NewClient(conn *websocket.Conn, manager *Manager, userID int, username string) *Client {
+func NewClient(conn *websocket.Conn, manager *Manager, userID int, username string, sessionID string) *Client {
 	return &Client{
-		conn:     conn,
-		manager:  manager,
-		egress:   make(chan []byte, 256),
-		userID:   userID,
-		username: username,
+		conn:        conn,
+		manager:     manager,
+		egress:      make(chan []byte, 256),
+		done:        make(chan struct{}),
+		userID:      userID,
+		username:    username,
+		sessionID:   sessionID,
 		activeChats: make(map[int]bool),
+		rooms:       make(map[int]bool),
 	}
 }
 
@@ -48,28 +58,56 @@ func (c *Client) ReadMessages() {
 			break
 		}
 
-		log.Printf("Received: %s", message)
+		if err := c.handleEvent(message); err != nil {
+			c.sendError(err.Error())
+		}
+	}
+}
+
+// send queues msg for this connection without blocking the caller. It must be
+// called with the manager lock held so it cannot race with RemoveClient closing egress.
+func (c *Client) send(msg []byte) {
+	select {
+	case c.egress <- msg:
+	default:
+		log.Printf("Dropping message for user %d: send buffer full", c.userID)
 	}
 }
 
 func (c *Client) WriteMessages() {
 	ticker := time.NewTicker(30 * time.Second)
+	sessionTicker := time.NewTicker(sessionCheckInterval)
 	defer func() {
 		ticker.Stop()
+		sessionTicker.Stop()
 		c.conn.Close()
+		close(c.done)
 	}()
 
 	for {
 		select {
 		case msg, ok := <-c.egress:
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				// The manager removed this client, e.g. during shutdown
+				c.conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+					time.Now().Add(time.Second))
 				return
 			}
 			c.conn.WriteMessage(websocket.TextMessage, msg)
 
 		case <-ticker.C:
 			c.conn.WriteMessage(websocket.PingMessage, nil)
+
+		case <-sessionTicker.C:
+			// Logged out or expired sessions lose their socket
+			if userID, err := sessions.ValidateSession(c.sessionID); err != nil || userID != c.userID {
+				log.Printf("Closing WebSocket for user %d: session no longer valid", c.userID)
+				c.conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session expired"),
+					time.Now().Add(time.Second))
+				return
+			}
 		}
 	}
 }
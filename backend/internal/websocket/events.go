@@ -0,0 +1,266 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"social-network/internal/groups"
+	"social-network/internal/messages"
+	"social-network/internal/models"
+	"strings"
+)
+
+// Event types exchanged over the socket
+const (
+	EventPrivateMessage = "private_message"
+	EventGroupMessage   = "group_message"
+	EventGroupChatJoin  = "group_chat_join"
+	EventMarkRead       = "mark_read"
+	EventMessagesRead   = "messages_read"
+	EventPresence       = "presence"
+	EventChatOpen       = "chat_open"
+	EventChatClose      = "chat_close"
+	EventTyping         = "typing"
+	EventError          = "error"
+)
+
+const maxMessageLength = 150
+
+// handleEvent decodes an incoming envelope and dispatches it by type
+func (c *Client) handleEvent(payload []byte) error {
+	var data json.RawMessage
+	envelope := models.WSMessage{Data: &data}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return fmt.Errorf("invalid message format")
+	}
+
+	switch envelope.Type {
+	case EventPrivateMessage:
+		return c.handlePrivateMessage(data)
+	case EventGroupMessage:
+		return c.handleGroupMessage(data)
+	case EventGroupChatJoin:
+		return c.handleGroupChatJoin(data)
+	case EventMarkRead:
+		return c.handleMarkRead(data)
+	case EventChatOpen:
+		return c.handleChatOpen(data)
+	case EventChatClose:
+		return c.handleChatClose(data)
+	case EventTyping:
+		return c.handleTyping(data)
+	default:
+		return fmt.Errorf("unknown message type: %s", envelope.Type)
+	}
+}
+
+func (c *Client) handlePrivateMessage(data json.RawMessage) error {
+	var msg models.Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("invalid private message")
+	}
+
+	msg.Type = EventPrivateMessage
+	msg.SenderID = c.userID
+	msg.Content = strings.TrimSpace(msg.Content)
+
+	if msg.Content == "" {
+		return fmt.Errorf("message content is required")
+	}
+	if len(msg.Content) > maxMessageLength {
+		return fmt.Errorf("message must be at most %d characters", maxMessageLength)
+	}
+	if msg.ReceiverID <= 0 || msg.ReceiverID == c.userID {
+		return fmt.Errorf("invalid receiver")
+	}
+
+	allowed, err := messages.CanMessage(c.userID, msg.ReceiverID)
+	if err != nil {
+		log.Printf("Error checking message permission: %v", err)
+		return fmt.Errorf("could not send message")
+	}
+	if !allowed {
+		return fmt.Errorf("you cannot message this user")
+	}
+
+	if err := messages.SaveMessage(&msg); err != nil {
+		log.Printf("Error saving message: %v", err)
+		return fmt.Errorf("could not send message")
+	}
+
+	out, err := json.Marshal(models.WSMessage{Type: EventPrivateMessage, Data: msg})
+	if err != nil {
+		return fmt.Errorf("could not send message")
+	}
+
+	// The sender's own connections receive the stored copy as an acknowledgement
+	c.manager.SendToUser(msg.ReceiverID, out)
+	c.manager.SendToUser(c.userID, out)
+	return nil
+}
+
+func (c *Client) handleGroupMessage(data json.RawMessage) error {
+	var msg models.GroupMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("invalid group message")
+	}
+
+	msg.Type = EventGroupMessage
+	msg.SenderID = c.userID
+	msg.SenderName = c.username
+	msg.Content = strings.TrimSpace(msg.Content)
+
+	if msg.Content == "" {
+		return fmt.Errorf("message content is required")
+	}
+	if len(msg.Content) > maxMessageLength {
+		return fmt.Errorf("message must be at most %d characters", maxMessageLength)
+	}
+
+	isMember, err := groups.IsGroupMember(c.userID, msg.ChatID)
+	if err != nil {
+		log.Printf("Error checking group membership: %v", err)
+		return fmt.Errorf("could not send message")
+	}
+	if !isMember {
+		return fmt.Errorf("you are not a member of this group")
+	}
+
+	if err := messages.SaveGroupMessage(&msg); err != nil {
+		log.Printf("Error saving group message: %v", err)
+		return fmt.Errorf("could not send message")
+	}
+
+	out, err := json.Marshal(models.WSMessage{Type: EventGroupMessage, Data: msg})
+	if err != nil {
+		return fmt.Errorf("could not send message")
+	}
+
+	// Members who joined after connecting may not be in the room yet
+	c.manager.JoinRoom(msg.ChatID, c)
+	c.manager.SendToRoom(msg.ChatID, out)
+	return nil
+}
+
+// handleGroupChatJoin subscribes this connection to a group room, used after
+// joining a group while already connected
+func (c *Client) handleGroupChatJoin(data json.RawMessage) error {
+	var req struct {
+		ChatID int `json:"chat_id"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return fmt.Errorf("invalid group chat request")
+	}
+
+	isMember, err := groups.IsGroupMember(c.userID, req.ChatID)
+	if err != nil {
+		log.Printf("Error checking group membership: %v", err)
+		return fmt.Errorf("could not join group chat")
+	}
+	if !isMember {
+		return fmt.Errorf("you are not a member of this group")
+	}
+
+	c.manager.JoinRoom(req.ChatID, c)
+	return nil
+}
+
+func (c *Client) handleMarkRead(data json.RawMessage) error {
+	var req struct {
+		UserID int `json:"user_id"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil || req.UserID <= 0 {
+		return fmt.Errorf("invalid read request")
+	}
+
+	if _, err := c.manager.markConversationRead(c.userID, req.UserID); err != nil {
+		log.Printf("Error marking messages read: %v", err)
+		return fmt.Errorf("could not mark messages as read")
+	}
+	return nil
+}
+
+// markConversationRead marks the conversation read and sends a receipt to the
+// original sender and to the reader's other connections so badges stay in sync
+func (m *Manager) markConversationRead(readerID, partnerID int) (int, error) {
+	lastID, err := messages.MarkConversationRead(readerID, partnerID)
+	if err != nil || lastID == 0 {
+		return lastID, err
+	}
+
+	out, err := json.Marshal(models.WSMessage{
+		Type: EventMessagesRead,
+		Data: models.ReadReceipt{ReaderID: readerID, PartnerID: partnerID, LastReadID: lastID},
+	})
+	if err != nil {
+		return lastID, err
+	}
+
+	m.SendToUser(partnerID, out)
+	m.SendToUser(readerID, out)
+	return lastID, nil
+}
+
+// handleChatOpen records that this connection has a conversation open, which
+// scopes typing indicators to the two participants
+func (c *Client) handleChatOpen(data json.RawMessage) error {
+	var req struct {
+		UserID int `json:"user_id"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil || req.UserID <= 0 || req.UserID == c.userID {
+		return fmt.Errorf("invalid chat request")
+	}
+
+	allowed, err := messages.CanMessage(c.userID, req.UserID)
+	if err != nil {
+		log.Printf("Error checking message permission: %v", err)
+		return fmt.Errorf("could not open chat")
+	}
+	if !allowed {
+		return fmt.Errorf("you cannot message this user")
+	}
+
+	c.manager.setActiveChat(c, req.UserID, true)
+	return nil
+}
+
+func (c *Client) handleChatClose(data json.RawMessage) error {
+	var req struct {
+		UserID int `json:"user_id"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return fmt.Errorf("invalid chat request")
+	}
+
+	c.manager.setActiveChat(c, req.UserID, false)
+	return nil
+}
+
+// handleTyping relays a typing indicator to the partner's connections that
+// have this conversation open. Typing events are never stored.
+func (c *Client) handleTyping(data json.RawMessage) error {
+	var typing models.TypingEvent
+	if err := json.Unmarshal(data, &typing); err != nil {
+		return fmt.Errorf("invalid typing event")
+	}
+	typing.SenderID = c.userID
+
+	out, err := json.Marshal(models.WSMessage{Type: EventTyping, Data: typing})
+	if err != nil {
+		return nil
+	}
+	c.manager.relayTyping(c, typing.ReceiverID, out)
+	return nil
+}
+
+// sendError reports a failed request back to this connection only
+func (c *Client) sendError(message string) {
+	out, err := json.Marshal(models.WSMessage{
+		Type: EventError,
+		Data: map[string]string{"message": message},
+	})
+	if err != nil {
+		return
+	}
+	c.manager.sendToClient(c, out)
+}
@@ -0,0 +1,51 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"social-network/internal/messages"
+	"social-network/internal/sessions"
+	"social-network/internal/utils"
+)
+
+// MarkReadHandler marks a conversation as read over REST and pushes the read receipt
+func MarkReadHandler(manager *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, _, err := sessions.GetUserFromSession(r)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			UserID int `json:"userId"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID <= 0 {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		lastReadID, err := manager.markConversationRead(userID, req.UserID)
+		if err != nil {
+			fmt.Println("Error marking messages read:", err)
+			http.Error(w, "Could not mark messages as read", http.StatusInternalServerError)
+			return
+		}
+
+		unread, err := messages.GetUnreadCount(userID)
+		if err != nil {
+			fmt.Println("Error counting unread messages:", err)
+		}
+
+		utils.SendJSONResponse(w, http.StatusOK, map[string]interface{}{
+			"lastReadId":     lastReadID,
+			"unreadMessages": unread,
+		})
+	}
+}
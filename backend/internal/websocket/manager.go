@@ -1,40 +1,203 @@
 package websocket
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 type Manager struct {
-	clients map[*Client]bool
+	clients map[int]map[*Client]bool // user ID -> open connections
+	rooms   map[int]map[*Client]bool // group ID -> member connections
 	sync.RWMutex
 }
 
 func NewManager() *Manager {
 	return &Manager{
-		clients: make(map[*Client]bool),
+		clients: make(map[int]map[*Client]bool),
+		rooms:   make(map[int]map[*Client]bool),
 	}
 }
 
 func (m *Manager) AddClient(c *Client) {
 	m.Lock()
-	m.clients[c] = true
+	firstConnection := len(m.clients[c.userID]) == 0
+	if firstConnection {
+		m.clients[c.userID] = make(map[*Client]bool)
+	}
+	m.clients[c.userID][c] = true
 	m.Unlock()
+
+	if firstConnection {
+		m.notifyPresence(c.userID, true)
+	}
 }
 
+// RemoveClient unregisters a connection and closes its send channel, which makes
+// WriteMessages send a close frame. It is safe to call more than once.
 func (m *Manager) RemoveClient(c *Client) {
 	m.Lock()
-	delete(m.clients, c)
+	conns := m.clients[c.userID]
+	if !conns[c] {
+		m.Unlock()
+		return
+	}
+	delete(conns, c)
+	lastConnection := len(conns) == 0
+	if lastConnection {
+		delete(m.clients, c.userID)
+	}
+	for groupID := range c.rooms {
+		m.leaveRoom(groupID, c)
+	}
+	close(c.egress)
 	m.Unlock()
+
+	if lastConnection {
+		m.notifyPresence(c.userID, false)
+	}
+}
+
+// IsOnline reports whether userID has at least one open connection
+func (m *Manager) IsOnline(userID int) bool {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.clients[userID]) > 0
+}
+
+// ConnectionCount returns the number of open connections across all users
+func (m *Manager) ConnectionCount() int {
+	m.RLock()
+	defer m.RUnlock()
+	count := 0
+	for _, conns := range m.clients {
+		count += len(conns)
+	}
+	return count
+}
+
+// JoinRoom adds a connection to a group chat room
+func (m *Manager) JoinRoom(groupID int, c *Client) {
+	m.Lock()
+	defer m.Unlock()
+	if !m.clients[c.userID][c] {
+		return
+	}
+	if m.rooms[groupID] == nil {
+		m.rooms[groupID] = make(map[*Client]bool)
+	}
+	m.rooms[groupID][c] = true
+	c.rooms[groupID] = true
+}
+
+// LeaveRoom removes every connection of userID from a group chat room. Call it
+// once the user is no longer a member so they stop receiving the room's messages.
+func (m *Manager) LeaveRoom(groupID, userID int) {
+	m.Lock()
+	defer m.Unlock()
+	for c := range m.clients[userID] {
+		m.leaveRoom(groupID, c)
+	}
+}
+
+// leaveRoom must be called with the lock held
+func (m *Manager) leaveRoom(groupID int, c *Client) {
+	delete(c.rooms, groupID)
+	room := m.rooms[groupID]
+	if room == nil {
+		return
+	}
+	delete(room, c)
+	if len(room) == 0 {
+		delete(m.rooms, groupID)
+	}
+}
+
+// SendToRoom queues msg on every connection in a group chat room
+func (m *Manager) SendToRoom(groupID int, msg []byte) {
+	m.RLock()
+	defer m.RUnlock()
+	for c := range m.rooms[groupID] {
+		c.send(msg)
+	}
+}
+
+// setActiveChat opens or closes a conversation on a connection
+func (m *Manager) setActiveChat(c *Client, partnerID int, open bool) {
+	m.Lock()
+	defer m.Unlock()
+	if open {
+		c.activeChats[partnerID] = true
+	} else {
+		delete(c.activeChats, partnerID)
+	}
+}
+
+// relayTyping forwards msg to partnerID's connections that have the chat with
+// the sender open, provided the sender has it open too
+func (m *Manager) relayTyping(from *Client, partnerID int, msg []byte) {
+	m.RLock()
+	defer m.RUnlock()
+	if !from.activeChats[partnerID] {
+		return
+	}
+	for c := range m.clients[partnerID] {
+		if c.activeChats[from.userID] {
+			c.send(msg)
+		}
+	}
+}
+
+// SendToUser queues msg on every open connection of userID. All server-side
+// pushes go through here so a user with several tabs receives them in each.
+func (m *Manager) SendToUser(userID int, msg []byte) {
+	m.RLock()
+	defer m.RUnlock()
+	for c := range m.clients[userID] {
+		c.send(msg)
+	}
+}
+
+// sendToClient queues msg on a single connection if it is still registered
+func (m *Manager) sendToClient(c *Client, msg []byte) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.clients[c.userID][c] {
+		c.send(msg)
+	}
 }
 
 func (m *Manager) Broadcast(msg []byte) {
 	m.RLock()
 	defer m.RUnlock()
-	for c := range m.clients {
-		select {
-		case c.egress <- msg:
-		default:
-			c.conn.Close()
-			delete(m.clients, c)
+	for _, conns := range m.clients {
+		for c := range conns {
+			c.send(msg)
 		}
 	}
 }
 
+// Shutdown removes every connection, which sends each a close frame, and waits
+// for their writers to finish or for ctx to expire
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.RLock()
+	var all []*Client
+	for _, conns := range m.clients {
+		for c := range conns {
+			all = append(all, c)
+		}
+	}
+	m.RUnlock()
+
+	for _, c := range all {
+		m.RemoveClient(c)
+	}
+
+	for _, c := range all {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
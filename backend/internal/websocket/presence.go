@@ -0,0 +1,103 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"social-network/internal/models"
+	"social-network/internal/sessions"
+	"social-network/internal/users"
+	"social-network/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxPresenceLookup = 100
+
+// notifyPresence tells userID's followers that they came online or went offline.
+// Going offline also records last_seen_at.
+func (m *Manager) notifyPresence(userID int, online bool) {
+	presence := models.Presence{UserID: userID, Online: online}
+	if !online {
+		if err := users.UpdateLastSeen(userID); err != nil {
+			log.Printf("Error updating last seen for user %d: %v", userID, err)
+		}
+		presence.LastSeenAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	followerIDs, err := users.GetFollowerIDs(userID)
+	if err != nil {
+		log.Printf("Error loading followers for user %d: %v", userID, err)
+		return
+	}
+	if len(followerIDs) == 0 {
+		return
+	}
+
+	out, err := json.Marshal(models.WSMessage{Type: EventPresence, Data: presence})
+	if err != nil {
+		return
+	}
+	for _, followerID := range followerIDs {
+		m.SendToUser(followerID, out)
+	}
+}
+
+// OnlineStatusHandler returns online status and last seen time for ?ids=1,2,3.
+// Users outside the caller's presence audience always show as offline.
+func OnlineStatusHandler(manager *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		viewerID, _, err := sessions.GetUserFromSession(r)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var ids []int
+		for _, part := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || id <= 0 {
+				continue
+			}
+			ids = append(ids, id)
+		}
+		if len(ids) == 0 {
+			http.Error(w, "Missing user IDs", http.StatusBadRequest)
+			return
+		}
+		if len(ids) > maxPresenceLookup {
+			http.Error(w, "Too many user IDs", http.StatusBadRequest)
+			return
+		}
+
+		visible, err := users.GetPresenceAudience(viewerID, ids)
+		if err != nil {
+			log.Printf("Error loading presence audience: %v", err)
+			http.Error(w, "Could not retrieve status", http.StatusInternalServerError)
+			return
+		}
+
+		lastSeen, err := users.GetLastSeen(ids)
+		if err != nil {
+			log.Printf("Error loading last seen: %v", err)
+			http.Error(w, "Could not retrieve status", http.StatusInternalServerError)
+			return
+		}
+
+		statuses := make([]models.Presence, 0, len(ids))
+		for _, id := range ids {
+			if !visible[id] {
+				statuses = append(statuses, models.Presence{UserID: id})
+				continue
+			}
+			status := models.Presence{UserID: id, Online: manager.IsOnline(id)}
+			if seen, ok := lastSeen[id]; ok && !status.Online {
+				status.LastSeenAt = seen.UTC().Format(time.RFC3339)
+			}
+			statuses = append(statuses, status)
+		}
+
+		utils.SendJSONResponse(w, http.StatusOK, statuses)
+	}
+}
@@ -3,7 +3,9 @@ package websocket
 import (
 	"log"
 	"net/http"
+	"social-network/internal/groups"
 	"social-network/internal/sessions"
+	"social-network/internal/utils"
 
 	"github.com/gorilla/websocket"
 )
@@ -11,31 +13,49 @@ import (
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool { // must change later
-		return true
+	CheckOrigin: func(r *http.Request) bool {
+		// Non-browser clients send no Origin; browsers must come from an allowed one
+		origin := r.Header.Get("Origin")
+		return origin == "" || utils.IsAllowedOrigin(origin)
 	},
 }
 
 func WebSocketHandler(manager *Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		conn, err := upgrader.Upgrade(w, r, nil)
+		// Authenticate before upgrading so a bad session gets a real 401
+		cookie, err := r.Cookie(sessions.SessionCookieName)
 		if err != nil {
-			log.Printf("WebSocket upgrade failed: %v", err)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
 		userID, username, err := sessions.GetUserFromSession(r)
 		if err != nil {
-			log.Printf("Error getting user: %v", err)
-			conn.Close()
+			log.Printf("Rejected WebSocket handshake: %v", err)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("WebSocket upgrade failed: %v", err)
 			return
 		}
-		client := NewClient(conn, manager, userID, username)
+
+		client := NewClient(conn, manager, userID, username, cookie.Value)
 		log.Printf("User %d (%s) connected via WebSocket", userID, username)
-		
+
 		manager.AddClient(client)
-		log.Printf("Total clients: %d", len(manager.clients))
-		
+		log.Printf("Total clients: %d", manager.ConnectionCount())
+
+		groupIDs, err := groups.GetUserGroupIDs(userID)
+		if err != nil {
+			log.Printf("Error loading groups for user %d: %v", userID, err)
+		}
+		for _, groupID := range groupIDs {
+			manager.JoinRoom(groupID, client)
+		}
+
 		go client.ReadMessages()
 		go client.WriteMessages()
 	}
@@ -71,7 +71,8 @@ export default function Group_Events({ group_id }) {
       const data = new FormData();
       data.append("title", formData.title);
       data.append("description", formData.description);
-      data.append("datetime", formData.datetime);
+      // datetime-local has no offset; send the instant so the server stores the right time
+      data.append("datetime", new Date(formData.datetime).toISOString());
       data.append("restrictedAge", formData.restrictedAge);
       data.append("groupid", formData.groupid);
       data.append("userid", formData.userid);
@@ -107,9 +108,10 @@ export default function Group_Events({ group_id }) {
   });
 
 const respondToEventMutation = useMutation({
-  mutationFn: ({ eventId, response }) =>
+  mutationFn: ({ eventId, occurrence, response }) =>
     axios.post("http://localhost:8080/groups/event-response", {
       event_id: eventId,
+      occurrence,
       user_id: user.id,
       group_id: group_id,
       response,
@@ -125,8 +127,8 @@ const respondToEventMutation = useMutation({
   },
 });
 
-const respondToEvent = (eventId, response) => {
-  respondToEventMutation.mutate({ eventId, response });
+const respondToEvent = (eventId, occurrence, response) => {
+  respondToEventMutation.mutate({ eventId, occurrence, response });
 };
 
   const handleChange = (e) => {
@@ -139,8 +141,9 @@ const respondToEvent = (eventId, response) => {
     createEvent.mutate(formData);
   };
 
-  // Only events with NO response
-  const notRespondedEvents = events.filter((event) => !event.user_response);
+  // Everything the user is not going to, so "maybe" and "not going" can still be changed
+  const notRespondedEvents = events.filter((event) => event.user_response !== "going");
+  const responseLabels = { maybe: "Maybe", not_going: "Not going" };
 
   return (
     <div className="relative min-h-[450px] max-h-[80vh] border border-gray-300 rounded-lg bg-white overflow-hidden">
@@ -171,13 +174,13 @@ const respondToEvent = (eventId, response) => {
         style={{ willChange: "transform" }}
       >
         <div className="p-6 pt-14 h-full overflow-y-auto custom-scrollbar">
-          <h2 className="text-lg font-semibold mb-3">📬 Not Responded</h2>
+          <h2 className="text-lg font-semibold mb-3">📬 Not Going Yet</h2>
           {notRespondedEvents.length === 0 ? (
             <div className="text-gray-400 text-center mt-10">No pending events</div>
           ) : (
             notRespondedEvents.map((event) => (
               <div
-                key={event.id}
+                key={`${event.id}-${event.occurrence}`}
                 className="bg-gray-100 border border-gray-200 rounded-lg p-3 mb-4 shadow-sm"
               >
                 <div className="flex flex-col">
@@ -185,16 +188,29 @@ const respondToEvent = (eventId, response) => {
                   <span className="text-xs text-gray-500">
                     {new Date(event.event_time).toLocaleString()}
                   </span>
+                  {event.user_response && (
+                    <span className="text-xs text-gray-600 mt-1">
+                      Your answer: {responseLabels[event.user_response]}
+                    </span>
+                  )}
                   <div className="flex gap-2 mt-2">
                     <button
-                      onClick={() => respondToEvent(event.id, "going")}
+                      onClick={() => respondToEvent(event.id, event.occurrence, "going")}
                       className="px-2 py-1 rounded bg-green-500 text-white text-xs hover:bg-green-600"
                     >
                       Going
                     </button>
                     <button
-                      onClick={() => respondToEvent(event.id, "not_going")}
-                      className="px-2 py-1 rounded bg-red-500 text-white text-xs hover:bg-red-600"
+                      onClick={() => respondToEvent(event.id, event.occurrence, "maybe")}
+                      disabled={event.user_response === "maybe"}
+                      className="px-2 py-1 rounded bg-yellow-500 text-white text-xs hover:bg-yellow-600 disabled:opacity-50"
+                    >
+                      Maybe
+                    </button>
+                    <button
+                      onClick={() => respondToEvent(event.id, event.occurrence, "not_going")}
+                      disabled={event.user_response === "not_going"}
+                      className="px-2 py-1 rounded bg-red-500 text-white text-xs hover:bg-red-600 disabled:opacity-50"
                     >
                       Not Going
                     </button>
@@ -214,7 +230,7 @@ const respondToEvent = (eventId, response) => {
         ) : (
           goingEvents.map((event) => (
             <div
-              key={event.id}
+              key={`${event.id}-${event.occurrence}`}
               className="bg-green-50 border border-green-200 rounded-lg p-4 shadow-sm transition hover:shadow-md"
             >
               <h3 className="text-md font-semibold">📌 {event.title}</h3>
@@ -1,19 +1,92 @@
-import { useQuery } from "@tanstack/react-query";
+import { useState } from "react";
+import { useInfiniteQuery } from "@tanstack/react-query";
 import axios from "axios";
 import defaultAvatar from "../../images/default-avatar.svg";
 
-export default function CommentList({ postId, isOpen, onClose }) {
-  const { data, isLoading, error } = useQuery({
-    queryKey: ["comments", postId],
-    queryFn: async () => {
+// Loads comments page by page; parentId 0 means the top-level comments
+function useComments(postId, parentId, enabled) {
+  const query = useInfiniteQuery({
+    queryKey: ["comments", postId, parentId],
+    queryFn: async ({ pageParam }) => {
       const response = await axios.get(
-        `http://localhost:8080/comments?postId=${postId}`,
+        `http://localhost:8080/comments?postId=${postId}&parentId=${parentId}&after=${pageParam}`,
         { withCredentials: true }
       );
       return response.data;
     },
-    enabled: isOpen,
+    initialPageParam: 0,
+    getNextPageParam: (lastPage) =>
+      lastPage.hasMore ? lastPage.nextCursor : undefined,
+    enabled,
   });
+  const comments = query.data?.pages.flatMap((page) => page.comments);
+  return { ...query, comments };
+}
+
+function LoadMoreButton({ query, label }) {
+  if (!query.hasNextPage) return null;
+  return (
+    <button
+      onClick={() => query.fetchNextPage()}
+      disabled={query.isFetchingNextPage}
+      className="text-xs text-purple-600 hover:underline disabled:opacity-50"
+    >
+      {query.isFetchingNextPage ? "Loading..." : label}
+    </button>
+  );
+}
+
+function Replies({ postId, parentId }) {
+  const query = useComments(postId, parentId, true);
+  if (query.isLoading) {
+    return <p className="text-xs text-gray-500">Loading replies...</p>;
+  }
+  return (
+    <div className="mt-2 space-y-2">
+      {query.comments?.map((comment) => (
+        <Comment key={comment.id} postId={postId} comment={comment} />
+      ))}
+      <LoadMoreButton query={query} label="Load more replies" />
+    </div>
+  );
+}
+
+function Comment({ postId, comment }) {
+  const [showReplies, setShowReplies] = useState(false);
+  return (
+    <div className="flex gap-2">
+      <img
+        src={defaultAvatar}
+        alt="avatar"
+        className="w-8 h-8 rounded-full object-cover bg-gray-200"
+      />
+      <div className="flex-1">
+        <p className="text-sm font-semibold">{comment.username}</p>
+        <p className="text-sm text-gray-700">
+          {comment.content}
+          {comment.edited && (
+            <span className="text-xs text-gray-400"> (edited)</span>
+          )}
+        </p>
+        {comment.replyCount > 0 && (
+          <button
+            onClick={() => setShowReplies(!showReplies)}
+            className="text-xs text-gray-500 hover:underline"
+          >
+            {showReplies
+              ? "Hide replies"
+              : `View ${comment.replyCount} ${comment.replyCount === 1 ? "reply" : "replies"}`}
+          </button>
+        )}
+        {showReplies && <Replies postId={postId} parentId={comment.id} />}
+      </div>
+    </div>
+  );
+}
+
+export default function CommentList({ postId, isOpen, onClose }) {
+  const query = useComments(postId, 0, isOpen);
+  const { comments: data, isLoading, error } = query;
 
   if (!isOpen) return null;
 
@@ -60,18 +133,9 @@ export default function CommentList({ postId, isOpen, onClose }) {
       {data && data.length > 0 && (
         <div className="space-y-3 max-h-60 overflow-y-auto">
           {data.map((comment) => (
-            <div key={comment.id} className="flex gap-2">
-              <img
-                src={defaultAvatar}
-                alt="avatar"
-                className="w-8 h-8 rounded-full object-cover bg-gray-200"
-              />
-              <div className="flex-1">
-                <p className="text-sm font-semibold">{comment.username}</p>
-                <p className="text-sm text-gray-700">{comment.content}</p>
-              </div>
-            </div>
+            <Comment key={comment.id} postId={postId} comment={comment} />
           ))}
+          <LoadMoreButton query={query} label="Load more comments" />
         </div>
       )}
     </div>
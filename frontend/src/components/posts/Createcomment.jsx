@@ -1,8 +1,9 @@
 // useCommentMutation.js
-import { useMutation } from "@tanstack/react-query";
+import { useMutation, useQueryClient } from "@tanstack/react-query";
 import axios from "axios";
 
 export function useCommentMutation(onSuccess) {
+  const queryClient = useQueryClient();
   return useMutation({
     mutationFn: async (userdata) => {
       // Transform comment to content as backend expects
@@ -15,8 +16,10 @@ export function useCommentMutation(onSuccess) {
       });
       return res.data;
     },
-    onSuccess: (data) => {
+    onSuccess: (data, userdata) => {
       console.log("Comment successful:", data);
+      // Reload the pages already shown; the new comment is at the end of the list
+      queryClient.invalidateQueries({ queryKey: ["comments", userdata.postId] });
       onSuccess?.();
     },
     onError: (error) => {
@@ -3,8 +3,12 @@ import { createRoot } from 'react-dom/client';
 import './index.css';
 import App from './App.jsx';
 
+import axios from 'axios';
 import { QueryClient, QueryClientProvider } from '@tanstack/react-query';
 
+// The backend identifies the user from the session cookie on every request
+axios.defaults.withCredentials = true;
+
 const queryClient = new QueryClient();
 
 createRoot(document.getElementById('root')).render(